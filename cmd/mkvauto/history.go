@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mmzim/mkvauto/internal/app"
+	"github.com/mmzim/mkvauto/internal/history"
+	"github.com/spf13/cobra"
+)
+
+func newHistoryCmd() *cobra.Command {
+	var jsonOut bool
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history [search]",
+		Short: "Search the history of ripped discs",
+		Long: "Lists earlier rips, newest first. The search matches disc names, output\n" +
+			"folders, fingerprints and file names.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			records, err := history.NewStore(app.HistoryPath()).Search(strings.Join(args, " "), limit)
+			if err != nil {
+				return fail("Error reading history: %v", err)
+			}
+			if jsonOut {
+				return printJSON(records)
+			}
+			printRecords(records)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print rips as JSON")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Maximum number of rips to list (0 = all)")
+
+	show := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show the titles, files and encode results of a rip",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return fail("Invalid rip ID: %s", args[0])
+			}
+			rec, err := history.NewStore(app.HistoryPath()).Get(id)
+			if err != nil {
+				return fail("Error reading history: %v", err)
+			}
+			if rec == nil {
+				return fail("No rip with ID %d", id)
+			}
+			if jsonOut {
+				return printJSON(rec)
+			}
+			printRecord(rec)
+			return nil
+		},
+	}
+	show.Flags().BoolVar(&jsonOut, "json", false, "Print the rip as JSON")
+
+	cmd.AddCommand(show)
+	return cmd
+}
+
+func printRecords(records []*history.Record) {
+	if len(records) == 0 {
+		fmt.Println("No rips found")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDATE\tDISC\tTYPE\tTITLES\tRAW\tENCODED\tSTATUS\tFOLDER")
+	for _, rec := range records {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d/%d\t%s\t%s\t%s\t%s\n",
+			rec.ID, rec.StartedAt.Format("2006-01-02 15:04"), rec.DiscName, rec.DiscType,
+			rec.Ripped(), len(rec.Titles), formatSize(rec.RawSize()), formatSize(rec.EncodedSize()),
+			rec.Status, rec.Folder)
+	}
+	w.Flush()
+}
+
+func printRecord(rec *history.Record) {
+	fmt.Printf("Disc:        %s (%s)\n", rec.DiscName, rec.DiscType)
+	fmt.Printf("Fingerprint: %s\n", rec.Fingerprint)
+	if rec.VolumeID != "" {
+		fmt.Printf("Volume ID:   %s\n", rec.VolumeID)
+	}
+	fmt.Printf("Drive:       %s\n", rec.Drive)
+	fmt.Printf("Folder:      %s\n", rec.Folder)
+	fmt.Printf("Started:     %s\n", rec.StartedAt.Format("2006-01-02 15:04:05"))
+	if rec.FinishedAt != nil {
+		fmt.Printf("Finished:    %s\n", rec.FinishedAt.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("Status:      %s\n", rec.Status)
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TITLE\tLENGTH\tRIP\tRAW\tENCODE\tENCODED\tFILE")
+	for _, t := range rec.Titles {
+		rip := "pending"
+		if t.Ripped {
+			rip = "ripped"
+		} else if t.RipError != "" {
+			rip = "failed"
+		}
+		encode := t.Encode
+		if encode == "" {
+			encode = "-"
+		}
+		file := t.EncodedPath
+		if file == "" {
+			file = t.Name
+		}
+		fmt.Fprintf(w, "%d\t%d:%02d:%02d\t%s\t%s\t%s\t%s\t%s\n",
+			t.ID, t.Seconds/3600, t.Seconds/60%60, t.Seconds%60, rip,
+			formatSize(t.RawSize), encode, formatSize(t.EncodedSize), file)
+	}
+	w.Flush()
+
+	for _, t := range rec.Titles {
+		if t.RipError != "" {
+			fmt.Printf("Title %d rip error: %s\n", t.ID, t.RipError)
+		}
+		if t.EncodeError != "" {
+			fmt.Printf("Title %d encode error: %s\n", t.ID, t.EncodeError)
+		}
+	}
+}
+
+// formatSize formats a file size for display, "-" if unknown
+func formatSize(bytes int64) string {
+	if bytes <= 0 {
+		return "-"
+	}
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -1,28 +1,84 @@
 package main
 
 import (
-	"flag"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
-	"path/filepath"
-	"strings"
-	"time"
 
-	"github.com/google/uuid"
 	"github.com/mmzim/mkvauto/internal/app"
 	"github.com/mmzim/mkvauto/internal/config"
-	"github.com/mmzim/mkvauto/internal/disk"
-	"github.com/mmzim/mkvauto/internal/encode"
+	"github.com/spf13/cobra"
 )
 
 func main() {
-	// Parse command-line flags
-	addFile := flag.String("add", "", "Add a file to the encoding queue (path to MKV file)")
-	addDiscType := flag.String("type", "auto", "Disc type for added file: bluray, dvd, or auto (default: auto)")
-	addOutput := flag.String("output", "", "Output path for encoded file (default: same directory with _encoded suffix)")
-	flag.Parse()
+	if err := newRootCmd().Execute(); err != nil {
+		// Errors from our commands are already reported, cobra's own are not
+		var reported reportedError
+		if !errors.As(err, &reported) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
 
-	// Load configuration
+func newRootCmd() *cobra.Command {
+	var headless, jsonLogs, verbose bool
+	var addFile, addDiscType, addOutput string
+
+	root := &cobra.Command{
+		Use:           "mkvauto",
+		Short:         "Automated MakeMKV ripping and HandBrake encoding",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Legacy --add flag, same as "queue add"
+			if addFile != "" {
+				if err := addFileToQueue(addFile, addDiscType, addOutput); err != nil {
+					return fail("Error adding file to queue: %v", err)
+				}
+				return nil
+			}
+
+			if headless {
+				return runHeadless(jsonLogs, verbose)
+			}
+			return runTUI()
+		},
+	}
+
+	root.Flags().BoolVar(&headless, "headless", false, "Run without the TUI, logging events to stdout (for systemd/daemon use)")
+	root.Flags().BoolVar(&jsonLogs, "json", false, "Use JSON log records in headless mode")
+	root.Flags().BoolVar(&verbose, "verbose", false, "Include MakeMKV/HandBrake output and status updates in headless logs")
+
+	root.Flags().StringVar(&addFile, "add", "", "Add a file to the encoding queue (path to MKV file)")
+	root.Flags().StringVar(&addDiscType, "type", "auto", "Disc type for added file: bluray, dvd, or auto")
+	root.Flags().StringVar(&addOutput, "output", "", "Output path for encoded file (default: same directory with _encoded suffix)")
+	root.Flags().MarkDeprecated("add", "use \"mkvauto queue add\" instead")
+
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "tui",
+			Short: "Run the interactive TUI (default)",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runTUI()
+			},
+		},
+		newQueueCmd(),
+		newScanMissingCmd(),
+		newRipCmd(),
+		newTrayCmd(),
+		newStatusCmd(),
+		newSeriesCmd(),
+		newHistoryCmd(),
+	)
+
+	return root
+}
+
+// loadConfig loads the configuration file, printing help if it can't be read
+func loadConfig() (*config.Config, error) {
 	configPath := os.Getenv("MKVAUTO_CONFIG")
 	if configPath == "" {
 		// Check default locations
@@ -42,108 +98,74 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		fmt.Fprintf(os.Stderr, "Please create a config file at %s\n", configPath)
 		fmt.Fprintf(os.Stderr, "See config.example.yaml for an example.\n")
-		os.Exit(1)
+		return nil, reportedError{err}
 	}
 
-	// Handle --add flag
-	if *addFile != "" {
-		if err := addFileToQueue(cfg, *addFile, *addDiscType, *addOutput); err != nil {
-			fmt.Fprintf(os.Stderr, "Error adding file to queue: %v\n", err)
-			os.Exit(1)
-		}
-		fmt.Printf("Successfully added %s to encoding queue\n", *addFile)
-		return
+	return cfg, nil
+}
+
+func runTUI() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
 	}
 
-	// Create and run application
-	application := app.New(cfg)
+	application, err := app.New(cfg)
+	if err != nil {
+		return fail("Configuration error: %v", err)
+	}
 	if err := application.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Application error: %v\n", err)
-		os.Exit(1)
+		return fail("Application error: %v", err)
 	}
+	return nil
 }
 
-func addFileToQueue(cfg *config.Config, sourcePath, discTypeStr, outputPath string) error {
-	// Validate source file exists
-	absSourcePath, err := filepath.Abs(sourcePath)
+func runHeadless(jsonLogs, verbose bool) error {
+	cfg, err := loadConfig()
 	if err != nil {
-		return fmt.Errorf("invalid source path: %w", err)
+		return err
 	}
 
-	if _, err := os.Stat(absSourcePath); os.IsNotExist(err) {
-		return fmt.Errorf("source file does not exist: %s", absSourcePath)
-	}
-
-	// Determine disc type
-	var discType disk.DiscType
-	switch strings.ToLower(discTypeStr) {
-	case "bluray", "blu-ray", "br":
-		discType = disk.DiscTypeBluRay
-	case "dvd":
-		discType = disk.DiscTypeDVD
-	case "auto":
-		// Auto-detect based on file size (rough heuristic: >8GB = BluRay)
-		info, err := os.Stat(absSourcePath)
-		if err != nil {
-			return fmt.Errorf("failed to stat file: %w", err)
-		}
-		if info.Size() > 8*1024*1024*1024 {
-			discType = disk.DiscTypeBluRay
-		} else {
-			discType = disk.DiscTypeDVD
-		}
-	default:
-		return fmt.Errorf("invalid disc type: %s (use bluray, dvd, or auto)", discTypeStr)
+	logger := newLogger(jsonLogs, verbose)
+	application, err := app.New(cfg)
+	if err != nil {
+		logger.Error("configuration error", "error", err)
+		return reportedError{err}
 	}
-
-	// Determine output path
-	var absOutputPath string
-	if outputPath != "" {
-		absOutputPath, err = filepath.Abs(outputPath)
-		if err != nil {
-			return fmt.Errorf("invalid output path: %w", err)
-		}
-	} else {
-		// Default: same directory, add _encoded suffix
-		dir := filepath.Dir(absSourcePath)
-		base := filepath.Base(absSourcePath)
-		ext := filepath.Ext(base)
-		nameWithoutExt := strings.TrimSuffix(base, ext)
-		absOutputPath = filepath.Join(dir, nameWithoutExt+"_encoded"+ext)
+	if err := application.RunHeadless(logger); err != nil {
+		slog.Error("application error", "error", err)
+		return reportedError{err}
 	}
+	return nil
+}
 
-	// Create queue item
-	item := &encode.QueueItem{
-		ID:         uuid.New().String(),
-		SourcePath: absSourcePath,
-		DestPath:   absOutputPath,
-		DiscType:   discType,
-		DiscName:   "Manual",
-		TitleName:  filepath.Base(absSourcePath),
-		Status:     encode.StatusQueued,
-		Progress:   0,
-		CreatedAt:  time.Now(),
-	}
+// reportedError marks an error that has already been printed
+type reportedError struct {
+	error
+}
 
-	// Load queue and add item
-	homeDir, _ := os.UserHomeDir()
-	stateDir := filepath.Join(homeDir, ".mkvauto")
-	statePath := filepath.Join(stateDir, "queue.json")
+// fail prints an error message to stderr and returns it
+func fail(format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	fmt.Fprintln(os.Stderr, err)
+	return reportedError{err}
+}
 
-	queue := encode.NewQueue(statePath)
-	if err := queue.LoadState(); err != nil {
-		// Ignore error if queue file doesn't exist yet
-		if !os.IsNotExist(err) {
-			return fmt.Errorf("failed to load queue: %w", err)
-		}
+// newLogger builds the structured logger used in headless mode
+func newLogger(jsonFormat, verbose bool) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: slog.LevelInfo}
+	if verbose {
+		opts.Level = slog.LevelDebug
 	}
 
-	queue.Add(item)
-
-	fmt.Printf("Added to queue:\n")
-	fmt.Printf("  Source: %s\n", absSourcePath)
-	fmt.Printf("  Output: %s\n", absOutputPath)
-	fmt.Printf("  Type: %s\n", discType.String())
+	var handler slog.Handler
+	if jsonFormat {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
 
-	return nil
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+	return logger
 }
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmzim/mkvauto/internal/api"
+	"github.com/mmzim/mkvauto/internal/app"
+	"github.com/mmzim/mkvauto/internal/disk"
+	"github.com/mmzim/mkvauto/internal/encode"
+	"github.com/spf13/cobra"
+)
+
+func newQueueCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "Inspect and manage the encoding queue",
+	}
+
+	var jsonOut bool
+	ls := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List queue items",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			items, err := listItems()
+			if err != nil {
+				return fail("Error listing queue: %v", err)
+			}
+			if jsonOut {
+				return printJSON(items)
+			}
+			printItems(items)
+			return nil
+		},
+	}
+	ls.Flags().BoolVar(&jsonOut, "json", false, "Print items as JSON")
+
+	var discType, output string
+	add := &cobra.Command{
+		Use:   "add <file>",
+		Short: "Add an existing MKV file to the queue",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := addFileToQueue(args[0], discType, output); err != nil {
+				return fail("Error adding file to queue: %v", err)
+			}
+			return nil
+		},
+	}
+	add.Flags().StringVar(&discType, "type", "auto", "Disc type: bluray, dvd, or auto (>8GB = Blu-ray)")
+	add.Flags().StringVar(&output, "output", "", "Output path for encoded file (default: same directory with _encoded suffix)")
+
+	rm := &cobra.Command{
+		Use:     "rm <id>",
+		Aliases: []string{"remove"},
+		Short:   "Remove an item by ID (or unique ID prefix), stopping it if encoding",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := removeItem(args[0]); err != nil {
+				return fail("Error removing item: %v", err)
+			}
+			return nil
+		},
+	}
+
+	retry := &cobra.Command{
+		Use:   "retry",
+		Short: "Requeue all failed items",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := withQueue(
+				func(ctx context.Context, client *api.Client) error { return client.RetryFailed(ctx) },
+				func(queue *encode.Queue) error { return queue.RetryFailed() },
+			)
+			if err != nil {
+				return fail("Error retrying failed items: %v", err)
+			}
+			fmt.Println("Failed items requeued")
+			return nil
+		},
+	}
+
+	clear := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove completed and failed items",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := withQueue(
+				func(ctx context.Context, client *api.Client) error { return client.ClearCompleted(ctx) },
+				func(queue *encode.Queue) error { return queue.ClearCompleted() },
+			)
+			if err != nil {
+				return fail("Error clearing queue: %v", err)
+			}
+			fmt.Println("Completed and failed items cleared")
+			return nil
+		},
+	}
+
+	move := &cobra.Command{
+		Use:       "move <id> up|down|top",
+		Short:     "Move a queued item up, down or to the top of the queue",
+		Args:      cobra.ExactArgs(2),
+		ValidArgs: []string{"up", "down", "top"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, err := findItem(args[0])
+			if err != nil {
+				return fail("Error: %v", err)
+			}
+			direction := args[1]
+			var local func(*encode.Queue) error
+			switch direction {
+			case "up":
+				local = func(queue *encode.Queue) error { return queue.MoveUp(target.ID) }
+			case "down":
+				local = func(queue *encode.Queue) error { return queue.MoveDown(target.ID) }
+			case "top":
+				local = func(queue *encode.Queue) error { return queue.MoveToTop(target.ID) }
+			default:
+				return fail("Unknown direction %q (use up, down or top)", direction)
+			}
+
+			err = withQueue(
+				func(ctx context.Context, client *api.Client) error { return client.MoveItem(ctx, target.ID, direction) },
+				local,
+			)
+			if err != nil {
+				return fail("Error moving item: %v", err)
+			}
+			fmt.Printf("Moved %s (%s) %s\n", shortID(target.ID), target.TitleName, direction)
+			return nil
+		},
+	}
+
+	priority := &cobra.Command{
+		Use:   "priority <id> <priority>",
+		Short: "Set the priority of a queued item (higher is encoded sooner, default 0)",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, err := findItem(args[0])
+			if err != nil {
+				return fail("Error: %v", err)
+			}
+			value, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fail("Invalid priority: %s", args[1])
+			}
+
+			err = withQueue(
+				func(ctx context.Context, client *api.Client) error { return client.SetPriority(ctx, target.ID, value) },
+				func(queue *encode.Queue) error { return queue.SetPriority(target.ID, value) },
+			)
+			if err != nil {
+				return fail("Error setting priority: %v", err)
+			}
+			fmt.Printf("Priority of %s (%s) set to %d\n", shortID(target.ID), target.TitleName, value)
+			return nil
+		},
+	}
+
+	cmd.AddCommand(ls, add, rm, retry, clear, move, priority,
+		newItemControlCmd("pause", "Pause the encode of an item", "Paused"),
+		newItemControlCmd("resume", "Resume a paused encode", "Resumed"),
+		newItemControlCmd("stop", "Stop the encode of an item (kept as failed, can be retried)", "Stopped"),
+	)
+	return cmd
+}
+
+// withQueue runs remote against a running instance, or local against
+// queue.json under the state lock when no instance is running
+func withQueue(remote func(context.Context, *api.Client) error, local func(*encode.Queue) error) error {
+	return withState(app.QueuePath(), remote, func() error {
+		queue := encode.NewQueue(app.QueuePath())
+		if err := queue.LoadState(); err != nil {
+			return fmt.Errorf("failed to load queue: %w", err)
+		}
+		return local(queue)
+	})
+}
+
+// withState runs remote against a running instance, or local while holding
+// the lock of statePath when no instance is running
+func withState(statePath string, remote func(context.Context, *api.Client) error, local func() error) error {
+	client := api.NewUnixClient(app.SocketPath())
+	ctx := context.Background()
+
+	// An instance that is starting up holds the lock file before its socket
+	// is ready, so retry for a few seconds before giving up on it
+	for attempt := 0; attempt < 20; attempt++ {
+		err := remote(ctx, client)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, api.ErrNotRunning) {
+			return err
+		}
+
+		unlock, err := encode.LockStateFile(statePath)
+		if err != nil {
+			return err
+		}
+		if app.InstanceRunning() {
+			unlock()
+			time.Sleep(500 * time.Millisecond)
+			continue
+		}
+
+		err = local()
+		unlock()
+		return err
+	}
+
+	return fmt.Errorf("mkvauto is running but its control socket is not responding (%s)", app.SocketPath())
+}
+
+// listItems returns the queue from the running instance or from queue.json
+func listItems() ([]api.Item, error) {
+	var items []api.Item
+	err := withQueue(
+		func(ctx context.Context, client *api.Client) error {
+			var err error
+			items, err = client.ListQueue(ctx)
+			return err
+		},
+		func(queue *encode.Queue) error {
+			for _, item := range queue.GetAll() {
+				items = append(items, api.NewItem(item))
+			}
+			return nil
+		},
+	)
+	return items, err
+}
+
+// findItem returns the queue item whose ID is id or, failing that, the only
+// one whose ID starts with id
+func findItem(id string) (api.Item, error) {
+	items, err := listItems()
+	if err != nil {
+		return api.Item{}, err
+	}
+
+	var matches []api.Item
+	for _, item := range items {
+		if item.ID == id {
+			matches = []api.Item{item}
+			break
+		}
+		if strings.HasPrefix(item.ID, id) {
+			matches = append(matches, item)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return api.Item{}, fmt.Errorf("no queue item matches %q", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return api.Item{}, fmt.Errorf("%q matches %d items, use a longer prefix", id, len(matches))
+	}
+}
+
+// removeItem removes the item found by findItem
+func removeItem(id string) error {
+	target, err := findItem(id)
+	if err != nil {
+		return err
+	}
+
+	err = withQueue(
+		func(ctx context.Context, client *api.Client) error { return client.RemoveItem(ctx, target.ID) },
+		func(queue *encode.Queue) error { return queue.Remove(target.ID) },
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed %s (%s)\n", shortID(target.ID), target.TitleName)
+	return nil
+}
+
+// newItemControlCmd builds a command controlling the encode of one item
+// in a running instance
+func newItemControlCmd(action, short, done string) *cobra.Command {
+	return &cobra.Command{
+		Use:   action + " <id>",
+		Short: short + " (requires a running instance)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, err := findItem(args[0])
+			if err != nil {
+				return fail("Error: %v", err)
+			}
+			err = api.NewUnixClient(app.SocketPath()).ControlItem(context.Background(), target.ID, action)
+			if errors.Is(err, api.ErrNotRunning) {
+				return fail("mkvauto is not running; nothing is being encoded")
+			}
+			if err != nil {
+				return fail("Error: %v", err)
+			}
+			fmt.Printf("%s %s (%s)\n", done, shortID(target.ID), target.TitleName)
+			return nil
+		},
+	}
+}
+
+func printItems(items []api.Item) {
+	if len(items) == 0 {
+		fmt.Println("No items in queue")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tPROGRESS\tPRIO\tTYPE\tTITLE\tERROR")
+	for _, item := range items {
+		progress := fmt.Sprintf("%.1f%%", item.Progress)
+		if item.Segments > 0 {
+			progress += fmt.Sprintf(" (%d/%d)", item.SegmentsDone, item.Segments)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			shortID(item.ID), item.Status, progress, item.Priority, item.DiscType, item.TitleName, item.Error)
+	}
+	w.Flush()
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// shortID abbreviates a UUID for display
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func addFileToQueue(sourcePath, discTypeStr, outputPath string) error {
+	// Validate source file exists
+	absSourcePath, err := filepath.Abs(sourcePath)
+	if err != nil {
+		return fmt.Errorf("invalid source path: %w", err)
+	}
+
+	if _, err := os.Stat(absSourcePath); os.IsNotExist(err) {
+		return fmt.Errorf("source file does not exist: %s", absSourcePath)
+	}
+
+	// Determine disc type
+	var discType disk.DiscType
+	if strings.ToLower(discTypeStr) == "auto" {
+		// Auto-detect based on file size (rough heuristic: >8GB = BluRay)
+		info, err := os.Stat(absSourcePath)
+		if err != nil {
+			return fmt.Errorf("failed to stat file: %w", err)
+		}
+		if info.Size() > 8*1024*1024*1024 {
+			discType = disk.DiscTypeBluRay
+		} else {
+			discType = disk.DiscTypeDVD
+		}
+	} else {
+		discType, err = disk.ParseDiscType(discTypeStr)
+		if err != nil {
+			return fmt.Errorf("%w (or auto)", err)
+		}
+	}
+
+	// Determine output path
+	var absOutputPath string
+	if outputPath != "" {
+		absOutputPath, err = filepath.Abs(outputPath)
+		if err != nil {
+			return fmt.Errorf("invalid output path: %w", err)
+		}
+	} else {
+		// Default: same directory, add _encoded suffix
+		dir := filepath.Dir(absSourcePath)
+		base := filepath.Base(absSourcePath)
+		ext := filepath.Ext(base)
+		nameWithoutExt := strings.TrimSuffix(base, ext)
+		absOutputPath = filepath.Join(dir, nameWithoutExt+"_encoded"+ext)
+	}
+
+	if err := submitItem(absSourcePath, absOutputPath, discType); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added to queue:\n")
+	fmt.Printf("  Source: %s\n", absSourcePath)
+	fmt.Printf("  Output: %s\n", absOutputPath)
+	fmt.Printf("  Type: %s\n", discType.String())
+
+	return nil
+}
+
+// submitItem hands the item to a running instance over its control socket,
+// or edits queue.json under the state lock when no instance is running
+func submitItem(sourcePath, destPath string, discType disk.DiscType) error {
+	req := api.AddRequest{
+		SourcePath: sourcePath,
+		DestPath:   destPath,
+		DiscType:   strings.ToLower(discType.String()),
+		DiscName:   "Manual",
+		TitleName:  filepath.Base(sourcePath),
+	}
+
+	return withQueue(
+		func(ctx context.Context, client *api.Client) error {
+			_, err := client.AddItem(ctx, req)
+			return err
+		},
+		func(queue *encode.Queue) error {
+			return addItemOffline(queue, req, discType)
+		},
+	)
+}
+
+// addItemOffline appends the item to the queue loaded from queue.json
+func addItemOffline(queue *encode.Queue, req api.AddRequest, discType disk.DiscType) error {
+	item := &encode.QueueItem{
+		ID:         uuid.New().String(),
+		SourcePath: req.SourcePath,
+		DestPath:   req.DestPath,
+		DiscType:   discType,
+		DiscName:   req.DiscName,
+		TitleName:  req.TitleName,
+		Status:     encode.StatusQueued,
+		Progress:   0,
+		CreatedAt:  time.Now(),
+	}
+
+	return queue.Add(item)
+}
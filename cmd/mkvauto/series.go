@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mmzim/mkvauto/internal/api"
+	"github.com/mmzim/mkvauto/internal/app"
+	"github.com/mmzim/mkvauto/internal/series"
+	"github.com/spf13/cobra"
+)
+
+func newSeriesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "series",
+		Short: "Rip a TV season across several discs with continuous episode numbers",
+	}
+
+	var season, episode int
+	start := &cobra.Command{
+		Use:   "start <show>",
+		Short: "Start a series session; following discs are ripped as episodes",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := api.SeriesRequest{Show: strings.Join(args, " "), Season: season, Episode: episode}
+
+			var session *series.Session
+			err := withSeries(
+				func(ctx context.Context, client *api.Client) error {
+					var err error
+					session, err = client.StartSeries(ctx, req)
+					return err
+				},
+				func(store *series.Store) error {
+					var err error
+					session, err = store.Start(req.Show, req.Season, req.Episode)
+					return err
+				},
+			)
+			if err != nil {
+				return fail("Error starting series: %v", err)
+			}
+			fmt.Printf("Series started: %s season %d, next disc starts at episode %d\n", session.Show, session.Season, session.NextEpisode)
+			return nil
+		},
+	}
+	start.Flags().IntVarP(&season, "season", "s", 1, "Season number")
+	start.Flags().IntVarP(&episode, "episode", "e", 1, "Episode number of the first title on the next disc")
+
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "Show the active series session",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var session *series.Session
+			err := withSeries(
+				func(ctx context.Context, client *api.Client) error {
+					var err error
+					session, err = client.Series(ctx)
+					return err
+				},
+				func(store *series.Store) error {
+					session = store.Current()
+					return nil
+				},
+			)
+			if err != nil {
+				return fail("Error reading series: %v", err)
+			}
+			if session == nil {
+				fmt.Println("No series session active")
+				return nil
+			}
+			fmt.Printf("Show:         %s\n", session.Show)
+			fmt.Printf("Season:       %d\n", session.Season)
+			fmt.Printf("Next episode: %d\n", session.NextEpisode)
+			fmt.Printf("Output:       %s\n", session.SeasonFolder())
+			if len(session.Discs) > 0 {
+				fmt.Printf("Discs:        %s\n", strings.Join(session.Discs, ", "))
+			}
+			return nil
+		},
+	}
+
+	end := &cobra.Command{
+		Use:   "end",
+		Short: "End the series session; following discs are ripped normally",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := withSeries(
+				func(ctx context.Context, client *api.Client) error { return client.EndSeries(ctx) },
+				func(store *series.Store) error { return store.End() },
+			)
+			if err != nil {
+				return fail("Error ending series: %v", err)
+			}
+			fmt.Println("Series session ended")
+			return nil
+		},
+	}
+
+	cmd.AddCommand(start, status, end)
+	return cmd
+}
+
+// withSeries runs remote against a running instance, or local against
+// series.json when no instance is running
+func withSeries(remote func(context.Context, *api.Client) error, local func(*series.Store) error) error {
+	return withState(app.SeriesPath(), remote, func() error {
+		store := series.NewStore(app.SeriesPath())
+		if err := store.Load(); err != nil {
+			return err
+		}
+		return local(store)
+	})
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/mmzim/mkvauto/internal/api"
+	"github.com/mmzim/mkvauto/internal/app"
+	"github.com/mmzim/mkvauto/internal/encode"
+	"github.com/spf13/cobra"
+)
+
+func newStatusCmd() *cobra.Command {
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show drive and queue status",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := api.NewUnixClient(app.SocketPath()).Status(context.Background())
+			if errors.Is(err, api.ErrNotRunning) {
+				// Not running: report the persisted queue only
+				items, listErr := listItems()
+				if listErr != nil {
+					return fail("Error reading queue: %v", listErr)
+				}
+				status = &api.Status{Running: false, Queue: summarizeItems(items)}
+			} else if err != nil {
+				return fail("Error getting status: %v", err)
+			}
+
+			if jsonOut {
+				return printJSON(status)
+			}
+			printStatus(status)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print status as JSON")
+
+	return cmd
+}
+
+func newScanMissingCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "scan-missing",
+		Short: "Queue raw files in the output directory that have no encoded version",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			running := false
+			err = withQueue(
+				func(ctx context.Context, client *api.Client) error {
+					running = true
+					return client.ScanMissing(ctx)
+				},
+				func(queue *encode.Queue) error {
+					running = false
+					_, err := app.ScanForMissingEncodes(cfg.OutputDir, queue, func(line string) {
+						fmt.Println(line)
+					})
+					return err
+				},
+			)
+			if err != nil {
+				return fail("Error scanning for missing encodes: %v", err)
+			}
+
+			if running {
+				fmt.Println("Scan started in the running instance, results will appear in its log")
+			}
+			return nil
+		},
+	}
+}
+
+func newRipCmd() *cobra.Command {
+	var drive string
+	var pause, resume bool
+
+	cmd := &cobra.Command{
+		Use:   "rip",
+		Short: "Rip the disc already in a drive (requires a running instance)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := api.NewUnixClient(app.SocketPath())
+			ctx := context.Background()
+
+			var err error
+			action, done := "starting rip", "Rip started"
+			switch {
+			case pause:
+				err = client.PauseRip(ctx, drive)
+				action, done = "pausing rip", "Rip paused"
+			case resume:
+				err = client.ResumeRip(ctx, drive)
+				action, done = "resuming rip", "Rip resumed"
+			default:
+				err = client.StartRip(ctx, drive)
+			}
+			if errors.Is(err, api.ErrNotRunning) {
+				return fail("mkvauto is not running; start it with \"mkvauto\" or \"mkvauto --headless\" first")
+			}
+			if err != nil {
+				return fail("Error %s: %v", action, err)
+			}
+
+			fmt.Printf("%s on %s\n", done, drive)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&drive, "drive", "/dev/sr0", "Drive to rip (device path, configured name, or e.g. sr0)")
+	cmd.Flags().BoolVar(&pause, "pause", false, "Pause the running rip instead")
+	cmd.Flags().BoolVar(&resume, "resume", false, "Resume a paused rip instead")
+	cmd.MarkFlagsMutuallyExclusive("pause", "resume")
+
+	return cmd
+}
+
+func newTrayCmd() *cobra.Command {
+	var drive string
+
+	cmd := &cobra.Command{
+		Use:       "tray open|close",
+		Short:     "Open or close the tray of a drive (requires a running instance)",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{"open", "close"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := api.NewUnixClient(app.SocketPath())
+			ctx := context.Background()
+
+			var err error
+			if args[0] == "open" {
+				err = client.OpenTray(ctx, drive)
+			} else {
+				err = client.CloseTray(ctx, drive)
+			}
+			if errors.Is(err, api.ErrNotRunning) {
+				return fail("mkvauto is not running; start it with \"mkvauto\" or \"mkvauto --headless\" first")
+			}
+			if err != nil {
+				return fail("Error moving tray: %v", err)
+			}
+
+			fmt.Printf("Tray %s requested on %s\n", args[0], drive)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&drive, "drive", "/dev/sr0", "Drive to open or close (device path, configured name, or e.g. sr0)")
+
+	return cmd
+}
+
+func printStatus(status *api.Status) {
+	if status.Running {
+		fmt.Println("mkvauto is running")
+	} else {
+		fmt.Println("mkvauto is not running")
+	}
+
+	if len(status.Drives) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "DRIVE\tSTATE\tDISC\tPROGRESS\tDETAIL")
+		for _, d := range status.Drives {
+			detail := d.Status
+			if d.LastError != "" {
+				detail = d.LastError
+			}
+			progress := ""
+			if d.TotalTitles > 0 {
+				progress = fmt.Sprintf("title %d/%d %.1f%%", d.CurrentTitle, d.TotalTitles, d.Progress)
+			}
+			state := d.State
+			if d.Paused {
+				state += " (paused)"
+			}
+			if d.State == "waiting" && d.Media == "tray_open" {
+				state += " (tray open)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.Name, state, d.Disc, progress, detail)
+		}
+		w.Flush()
+	}
+
+	q := status.Queue
+	fmt.Println()
+	fmt.Printf("Queue: %d items (%d queued, %d encoding, %d complete, %d failed)\n",
+		q.Total, q.Queued, q.Encoding, q.Complete, q.Failed)
+	if h := status.Hold; h != nil {
+		switch {
+		case h.Reason != "" && h.Next != "":
+			fmt.Printf("Encodes held: %s, %s\n", h.Reason, h.Next)
+		case h.Reason != "":
+			fmt.Printf("Encodes held: %s\n", h.Reason)
+		case h.Next != "":
+			fmt.Printf("Schedule: %s\n", h.Next)
+		}
+		if h.Throttle != "" {
+			fmt.Printf("Encoder priority lowered: %s\n", h.Throttle)
+		}
+	}
+}
+
+// summarizeItems counts API items by status
+func summarizeItems(items []api.Item) api.QueueSummary {
+	var summary api.QueueSummary
+	for _, item := range items {
+		summary.Total++
+		switch item.Status {
+		case encode.StatusQueued.String(), encode.StatusPaused.String():
+			summary.Queued++
+		case encode.StatusEncoding.String():
+			summary.Encoding++
+		case encode.StatusComplete.String():
+			summary.Complete++
+		case encode.StatusFailed.String():
+			summary.Failed++
+		}
+	}
+	return summary
+}
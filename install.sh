@@ -177,8 +177,8 @@ if [[ $REPLY =~ ^[Yy]$ ]]; then
     echo ""
     read -p "Paste your Discord webhook URL: " DISCORD_WEBHOOK
 else
-    DISCORD_WEBHOOK="https://discord.com/api/webhooks/YOUR_WEBHOOK_URL"
-    warn "Skipping Discord setup. You can add it later in config.yaml"
+    DISCORD_WEBHOOK=""
+    warn "Skipping Discord setup. You can add notifiers later in config.yaml"
 fi
 
 # ============================================
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/mmzim/mkvauto/internal/series"
+)
+
+// ErrNotRunning is returned by Client when no instance is listening on the socket
+var ErrNotRunning = errors.New("mkvauto is not running")
+
+// Client talks to the control API of a running instance
+type Client struct {
+	http *http.Client
+	base string
+}
+
+// NewUnixClient creates a client for the control socket at socketPath
+func NewUnixClient(socketPath string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+
+	return &Client{
+		http: &http.Client{Transport: transport, Timeout: 10 * time.Second},
+		base: "http://mkvauto",
+	}
+}
+
+// AddItem adds an item to the running instance's queue
+func (c *Client) AddItem(ctx context.Context, req AddRequest) (*Item, error) {
+	var item Item
+	if err := c.do(ctx, http.MethodPost, "/api/queue", req, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// Status returns drive and queue state
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var status Status
+	if err := c.do(ctx, http.MethodGet, "/api/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// ListQueue returns all queue items
+func (c *Client) ListQueue(ctx context.Context) ([]Item, error) {
+	var items []Item
+	if err := c.do(ctx, http.MethodGet, "/api/queue", nil, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// RemoveItem removes a queue item, stopping it first if it is encoding
+func (c *Client) RemoveItem(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/queue/"+url.PathEscape(id), nil, nil)
+}
+
+// ControlItem pauses, resumes, stops or deletes the encode of a queue item
+func (c *Client) ControlItem(ctx context.Context, id, action string) error {
+	return c.do(ctx, http.MethodPost, "/api/queue/"+url.PathEscape(id)+"/"+url.PathEscape(action), nil, nil)
+}
+
+// MoveItem moves a queued item "up", "down" or to the "top" of the queue
+func (c *Client) MoveItem(ctx context.Context, id, direction string) error {
+	return c.do(ctx, http.MethodPost, "/api/queue/"+url.PathEscape(id)+"/move/"+url.PathEscape(direction), nil, nil)
+}
+
+// SetPriority changes the priority of a queued item
+func (c *Client) SetPriority(ctx context.Context, id string, priority int) error {
+	return c.do(ctx, http.MethodPut, "/api/queue/"+url.PathEscape(id)+"/priority", PriorityRequest{Priority: priority}, nil)
+}
+
+// RetryFailed requeues failed items
+func (c *Client) RetryFailed(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/api/queue/retry", nil, nil)
+}
+
+// ClearCompleted removes completed and failed items
+func (c *Client) ClearCompleted(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/api/queue/clear", nil, nil)
+}
+
+// ScanMissing starts a scan for raw files missing encoded versions
+func (c *Client) ScanMissing(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/api/queue/scan-missing", nil, nil)
+}
+
+// StartRip starts processing the disc in a drive
+func (c *Client) StartRip(ctx context.Context, drive string) error {
+	return c.do(ctx, http.MethodPost, "/api/drives/"+url.PathEscape(drive)+"/rip", nil, nil)
+}
+
+// PauseRip suspends the rip running on a drive
+func (c *Client) PauseRip(ctx context.Context, drive string) error {
+	return c.do(ctx, http.MethodPost, "/api/drives/"+url.PathEscape(drive)+"/pause", nil, nil)
+}
+
+// ResumeRip continues a paused rip
+func (c *Client) ResumeRip(ctx context.Context, drive string) error {
+	return c.do(ctx, http.MethodPost, "/api/drives/"+url.PathEscape(drive)+"/resume", nil, nil)
+}
+
+// OpenTray ejects the tray of an idle drive
+func (c *Client) OpenTray(ctx context.Context, drive string) error {
+	return c.do(ctx, http.MethodPost, "/api/drives/"+url.PathEscape(drive)+"/open", nil, nil)
+}
+
+// CloseTray closes the tray of a drive
+func (c *Client) CloseTray(ctx context.Context, drive string) error {
+	return c.do(ctx, http.MethodPost, "/api/drives/"+url.PathEscape(drive)+"/close", nil, nil)
+}
+
+// Series returns the active series session, or nil if there is none
+func (c *Client) Series(ctx context.Context) (*series.Session, error) {
+	var session *series.Session
+	if err := c.do(ctx, http.MethodGet, "/api/series", nil, &session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// StartSeries starts a series session, replacing any active one
+func (c *Client) StartSeries(ctx context.Context, req SeriesRequest) (*series.Session, error) {
+	var session series.Session
+	if err := c.do(ctx, http.MethodPut, "/api/series", req, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// EndSeries ends the active series session
+func (c *Client) EndSeries(ctx context.Context) error {
+	return c.do(ctx, http.MethodDelete, "/api/series", nil, nil)
+}
+
+// do sends a request and decodes the JSON response into out (if non-nil)
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// A missing or stale socket means there's no instance to talk to
+		if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) {
+			return ErrNotRunning
+		}
+		return fmt.Errorf("control request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return errors.New(apiErr.Error)
+		}
+		return fmt.Errorf("control request returned status %d", resp.StatusCode)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Event types published on the stream
+const (
+	EventSnapshot       = "snapshot"
+	EventMediaChanged   = "media_changed"
+	EventDiscInserted   = "disc_inserted"
+	EventStatus         = "status"
+	EventScanComplete   = "scan_complete"
+	EventTitleSelection = "title_selection"
+	EventKnownDisc      = "known_disc"
+	EventMetadataMatch  = "metadata_match"
+	EventRipProgress    = "rip_progress"
+	EventRipComplete    = "rip_complete"
+	EventRipCancelled   = "rip_cancelled"
+	EventRipPaused      = "rip_paused"
+	EventEncodeProgress = "encode_progress"
+	EventEncodeComplete = "encode_complete"
+	EventEncodeHeld     = "encode_held"
+	EventError          = "error"
+	EventLog            = "log"
+)
+
+// Event is a single pipeline event as sent to stream subscribers
+type Event struct {
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Snapshot is the first event every subscriber receives
+type Snapshot struct {
+	Drives []DriveStatus `json:"drives"`
+	Queue  []Item        `json:"queue"`
+	Hold   *EncodeHold   `json:"hold,omitempty"`
+}
+
+// DriveEvent is the payload of drive-level events (inserted, complete, cancelled)
+type DriveEvent struct {
+	Drive string `json:"drive"`
+}
+
+// MediaChangedEvent is the payload of media_changed, sent when a disc is
+// inserted or removed or the tray opens or closes
+type MediaChangedEvent struct {
+	Drive string `json:"drive"`
+	Media string `json:"media"`           // no_disc, tray_open, not_ready, disc, error or missing
+	Error string `json:"error,omitempty"` // Why the drive can't be read
+}
+
+// RipPausedEvent is the payload of rip_paused
+type RipPausedEvent struct {
+	Drive  string `json:"drive"`
+	Paused bool   `json:"paused"`
+}
+
+// StatusEvent is the payload of status updates from MakeMKV
+type StatusEvent struct {
+	Drive  string `json:"drive"`
+	Status string `json:"status"`
+}
+
+// ScanCompleteEvent is the payload of scan_complete
+type ScanCompleteEvent struct {
+	Drive    string `json:"drive"`
+	Disc     string `json:"disc"`
+	DiscType string `json:"disc_type"`
+}
+
+// TitleSelectionEvent is the payload of title_selection
+type TitleSelectionEvent struct {
+	Drive   string  `json:"drive"`
+	Titles  []Title `json:"titles"`
+	Auto    bool    `json:"auto"`                      // Checked titles are the automatic selection
+	Timeout int     `json:"timeout_seconds,omitempty"` // Seconds until the automatic selection proceeds
+}
+
+// KnownDiscEvent is the payload of known_disc, sent when a disc that was
+// ripped before is inserted and the user is asked whether to rip it again
+type KnownDiscEvent struct {
+	Drive string        `json:"drive"`
+	Rips  []PreviousRip `json:"rips"` // Newest first
+}
+
+// MetadataMatchEvent is the payload of metadata_match, sent when the user is
+// asked to pick the movie or show a disc holds
+type MetadataMatchEvent struct {
+	Drive   string          `json:"drive"`
+	Query   string          `json:"query"`   // Title looked up, from the disc label
+	Matches []MetadataMatch `json:"matches"` // Best match first
+}
+
+// MetadataMatch is a movie or show offered for a disc
+type MetadataMatch struct {
+	Name     string `json:"name"`
+	Runtime  string `json:"runtime,omitempty"`
+	Overview string `json:"overview,omitempty"`
+}
+
+// PreviousRip is an earlier rip of a disc from the rip history
+type PreviousRip struct {
+	StartedAt time.Time `json:"started_at"`
+	Folder    string    `json:"folder"`
+	Ripped    int       `json:"ripped"` // Titles ripped
+	Titles    int       `json:"titles"` // Titles selected
+	Status    string    `json:"status"`
+}
+
+// Title is a disc title offered for selection
+type Title struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Duration string `json:"duration"`
+	Size     string `json:"size"`
+	Info     string `json:"info,omitempty"` // Source playlist, resolution and audio languages
+	Selected bool   `json:"selected"`
+}
+
+// RipProgressEvent is the payload of rip_progress
+type RipProgressEvent struct {
+	Drive        string  `json:"drive"`
+	Progress     float64 `json:"progress"`
+	CurrentTitle int     `json:"current_title"`
+	TotalTitles  int     `json:"total_titles"`
+}
+
+// EncodeHold is the payload of encode_held and tells whether new
+// encodes are held back, e.g. outside the encode window or while the
+// machine is too loaded or too hot
+type EncodeHold struct {
+	Reason   string `json:"reason,omitempty"`   // Empty while encodes run
+	Next     string `json:"next,omitempty"`     // When the encode window opens or closes next
+	Throttle string `json:"throttle,omitempty"` // Why encodes run at lowered priority
+}
+
+// EncodeProgressEvent is the payload of encode_progress and encode_complete
+type EncodeProgressEvent struct {
+	ItemID   string  `json:"item_id"`
+	Progress float64 `json:"progress"`
+}
+
+// ErrorEvent is the payload of error
+type ErrorEvent struct {
+	Drive string `json:"drive,omitempty"`
+	Error string `json:"error"`
+}
+
+// LogEvent is the payload of log
+type LogEvent struct {
+	Line   string `json:"line"`
+	Output bool   `json:"output,omitempty"` // Raw makemkvcon or HandBrake output
+}
+
+// Broker fans events out to stream subscribers
+// Slow subscribers drop events rather than blocking the pipeline
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish sends an event to all subscribers
+func (b *Broker) Publish(eventType string, data interface{}) {
+	event := Event{
+		Type: eventType,
+		Time: time.Now(),
+		Data: data,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber is behind, drop the event
+		}
+	}
+}
+
+// Subscribe registers a new subscriber
+// The returned function must be called to unsubscribe
+func (b *Broker) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 256)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+	}
+}
+
+// handleEvents streams events as Server-Sent Events
+// Each subscriber first receives a snapshot of drive and queue state
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	// Subscribe before taking the snapshot so nothing in between is lost
+	events, unsubscribe := s.events.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	items := s.queue.GetAll()
+	snapshot := Snapshot{
+		Drives: s.ctrl.Drives(),
+		Queue:  make([]Item, len(items)),
+	}
+	for i, item := range items {
+		snapshot.Queue[i] = NewItem(item)
+	}
+	if hold := s.ctrl.Hold(); hold != (EncodeHold{}) {
+		snapshot.Hold = &hold
+	}
+	if err := writeEvent(w, Event{Type: EventSnapshot, Time: time.Now(), Data: snapshot}); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event := <-events:
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes one event in SSE wire format
+func writeEvent(w http.ResponseWriter, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
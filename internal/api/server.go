@@ -0,0 +1,594 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmzim/mkvauto/internal/disk"
+	"github.com/mmzim/mkvauto/internal/encode"
+	"github.com/mmzim/mkvauto/internal/series"
+)
+
+// Controller is implemented by the app to expose the rip pipeline and encode worker
+type Controller interface {
+	// Drives returns the current rip state of every configured drive
+	Drives() []DriveStatus
+	// ControlEncode sends a control command to the encode of an item,
+	// or to every running encode if itemID is empty
+	ControlEncode(itemID string, ctrl encode.WorkerControl) error
+	// CancelRip cancels processing on a drive and ejects its disc
+	CancelRip(drive string) error
+	// PauseRip suspends (or resumes) the rip running on a drive
+	PauseRip(drive string, pause bool) error
+	// MoveTray opens (or closes) the tray of an idle drive
+	MoveTray(drive string, open bool) error
+	// StartRip starts processing the disc already in a drive
+	StartRip(drive string) error
+	// Hold tells whether new encodes are held back
+	Hold() EncodeHold
+	// ScanMissing starts a scan for raw files without encoded versions
+	ScanMissing() error
+}
+
+// DriveStatus is the rip state of a single drive
+type DriveStatus struct {
+	Device       string  `json:"device"`
+	Name         string  `json:"name"`
+	State        string  `json:"state"`
+	Status       string  `json:"status,omitempty"`
+	Disc         string  `json:"disc,omitempty"`
+	DiscType     string  `json:"disc_type,omitempty"`
+	Progress     float64 `json:"progress"`
+	CurrentTitle int     `json:"current_title,omitempty"`
+	TotalTitles  int     `json:"total_titles,omitempty"`
+	Paused       bool    `json:"paused,omitempty"`
+	Media        string  `json:"media,omitempty"`       // no_disc, tray_open, not_ready, disc, error or missing
+	MediaError   string  `json:"media_error,omitempty"` // Why the drive can't be read
+	LastError    string  `json:"last_error,omitempty"`  // Latest failure; the state is error only if it stopped the disc
+}
+
+// Item is the API representation of a queue item
+type Item struct {
+	ID          string     `json:"id"`
+	SourcePath  string     `json:"source_path"`
+	DestPath    string     `json:"dest_path"`
+	DiscType    string     `json:"disc_type"`
+	DiscName    string     `json:"disc_name"`
+	TitleName   string     `json:"title_name"`
+	Status      string     `json:"status"`
+	Progress    float64    `json:"progress"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Priority    int        `json:"priority,omitempty"`
+
+	// Segmented encodes resume after the last done segment
+	Segments     int `json:"segments,omitempty"`
+	SegmentsDone int `json:"segments_done,omitempty"`
+}
+
+// NewItem converts a queue item for output
+func NewItem(item *encode.QueueItem) Item {
+	done, total := item.SegmentCount()
+	return Item{
+		ID:          item.ID,
+		SourcePath:  item.SourcePath,
+		DestPath:    item.DestPath,
+		DiscType:    item.DiscType.String(),
+		DiscName:    item.DiscName,
+		TitleName:   item.TitleName,
+		Status:      item.Status.String(),
+		Progress:    item.Progress,
+		CreatedAt:   item.CreatedAt,
+		StartedAt:   item.StartedAt,
+		CompletedAt: item.CompletedAt,
+		Error:       item.Error,
+		Priority:    item.Priority,
+
+		Segments:     total,
+		SegmentsDone: done,
+	}
+}
+
+// AddRequest is the body of POST /api/queue
+type AddRequest struct {
+	SourcePath string `json:"source_path"`
+	DestPath   string `json:"dest_path"`
+	DiscType   string `json:"disc_type"`            // "bluray" or "dvd"
+	DiscName   string `json:"disc_name,omitempty"`  // Defaults to "Manual"
+	TitleName  string `json:"title_name,omitempty"` // Defaults to the source filename
+	Priority   int    `json:"priority,omitempty"`   // Higher is encoded sooner
+}
+
+// PriorityRequest is the body of PUT /api/queue/{id}/priority
+type PriorityRequest struct {
+	Priority int `json:"priority"`
+}
+
+// SeriesRequest is the body of PUT /api/series
+type SeriesRequest struct {
+	Show    string `json:"show"`
+	Season  int    `json:"season"`
+	Episode int    `json:"episode,omitempty"` // First episode number, defaults to 1
+}
+
+// Status is the combined state returned by GET /api/status
+type Status struct {
+	Running bool          `json:"running"`
+	Drives  []DriveStatus `json:"drives"`
+	Queue   QueueSummary  `json:"queue"`
+	Hold    *EncodeHold   `json:"hold,omitempty"`
+}
+
+// QueueSummary counts queue items by status
+type QueueSummary struct {
+	Total    int `json:"total"`
+	Queued   int `json:"queued"`
+	Encoding int `json:"encoding"`
+	Complete int `json:"complete"`
+	Failed   int `json:"failed"`
+}
+
+// ErrUnknownDrive is returned by Controller.CancelRip for drives that aren't configured
+var ErrUnknownDrive = errors.New("unknown drive")
+
+// ErrBusy is returned when a request can't be accepted right now
+var ErrBusy = errors.New("busy, try again")
+
+// ErrNoDisc is returned by Controller.StartRip when the drive is empty
+var ErrNoDisc = errors.New("no disc in drive")
+
+// ErrNotRipping is returned by Controller.PauseRip when no rip is running
+var ErrNotRipping = errors.New("drive is not ripping")
+
+// ErrNotEncoding is returned when controlling a queue item that isn't encoding
+var ErrNotEncoding = errors.New("item is not encoding")
+
+type Server struct {
+	queue  *encode.Queue
+	series *series.Store
+	ctrl   Controller
+	events *Broker
+	mux    *http.ServeMux
+}
+
+func NewServer(queue *encode.Queue, sessions *series.Store, ctrl Controller, events *Broker) *Server {
+	s := &Server{
+		queue:  queue,
+		series: sessions,
+		ctrl:   ctrl,
+		events: events,
+		mux:    http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /api/status", s.handleStatus)
+	s.mux.HandleFunc("GET /api/drives", s.handleDrives)
+	s.mux.HandleFunc("POST /api/drives/{drive}/cancel", s.handleCancelRip)
+	s.mux.HandleFunc("POST /api/drives/{drive}/rip", s.handleStartRip)
+	s.mux.HandleFunc("POST /api/drives/{drive}/pause", s.handlePauseRip)
+	s.mux.HandleFunc("POST /api/drives/{drive}/resume", s.handleResumeRip)
+	s.mux.HandleFunc("POST /api/drives/{drive}/open", s.handleOpenTray)
+	s.mux.HandleFunc("POST /api/drives/{drive}/close", s.handleCloseTray)
+	s.mux.HandleFunc("GET /api/queue", s.handleListQueue)
+	s.mux.HandleFunc("POST /api/queue", s.handleAddItem)
+	s.mux.HandleFunc("GET /api/queue/{id}", s.handleGetItem)
+	s.mux.HandleFunc("DELETE /api/queue/{id}", s.handleRemoveItem)
+	s.mux.HandleFunc("POST /api/queue/{id}/{action}", s.handleItemControl)
+	s.mux.HandleFunc("POST /api/queue/{id}/move/{direction}", s.handleMoveItem)
+	s.mux.HandleFunc("PUT /api/queue/{id}/priority", s.handleSetPriority)
+	s.mux.HandleFunc("POST /api/queue/retry", s.handleRetry)
+	s.mux.HandleFunc("POST /api/queue/clear", s.handleClear)
+	s.mux.HandleFunc("POST /api/queue/scan-missing", s.handleScanMissing)
+	s.mux.HandleFunc("POST /api/encode/{action}", s.handleEncodeControl)
+	s.mux.HandleFunc("GET /api/series", s.handleGetSeries)
+	s.mux.HandleFunc("PUT /api/series", s.handleStartSeries)
+	s.mux.HandleFunc("DELETE /api/series", s.handleEndSeries)
+	s.mux.HandleFunc("GET /api/events", s.handleEvents)
+
+	return s
+}
+
+// Handler returns the HTTP handler serving the API
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Serve serves the API on the listener until ctx is cancelled
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		// Derive request contexts from ctx so event streams end on shutdown
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// ListenAndServe listens on a TCP address and serves the API until ctx is cancelled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return s.Serve(ctx, ln)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status := Status{
+		Running: true,
+		Drives:  s.ctrl.Drives(),
+		Queue:   Summarize(s.queue.GetAll()),
+	}
+	if hold := s.ctrl.Hold(); hold != (EncodeHold{}) {
+		status.Hold = &hold
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// Summarize counts queue items by status
+func Summarize(items []*encode.QueueItem) QueueSummary {
+	var summary QueueSummary
+	for _, item := range items {
+		summary.Total++
+		switch item.Status {
+		case encode.StatusQueued, encode.StatusPaused:
+			summary.Queued++
+		case encode.StatusEncoding:
+			summary.Encoding++
+		case encode.StatusComplete:
+			summary.Complete++
+		case encode.StatusFailed:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+func (s *Server) handleDrives(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.ctrl.Drives())
+}
+
+func (s *Server) handleCancelRip(w http.ResponseWriter, r *http.Request) {
+	if err := s.ctrl.CancelRip(r.PathValue("drive")); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handlePauseRip(w http.ResponseWriter, r *http.Request) {
+	if err := s.ctrl.PauseRip(r.PathValue("drive"), true); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleResumeRip(w http.ResponseWriter, r *http.Request) {
+	if err := s.ctrl.PauseRip(r.PathValue("drive"), false); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleOpenTray(w http.ResponseWriter, r *http.Request) {
+	if err := s.ctrl.MoveTray(r.PathValue("drive"), true); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleCloseTray(w http.ResponseWriter, r *http.Request) {
+	if err := s.ctrl.MoveTray(r.PathValue("drive"), false); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleStartRip(w http.ResponseWriter, r *http.Request) {
+	if err := s.ctrl.StartRip(r.PathValue("drive")); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleListQueue(w http.ResponseWriter, r *http.Request) {
+	items := s.queue.GetAll()
+	out := make([]Item, len(items))
+	for i, item := range items {
+		out[i] = NewItem(item)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleAddItem(w http.ResponseWriter, r *http.Request) {
+	var req AddRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if !filepath.IsAbs(req.SourcePath) || !filepath.IsAbs(req.DestPath) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("source_path and dest_path must be absolute paths"))
+		return
+	}
+	if _, err := os.Stat(req.SourcePath); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("source file does not exist: %s", req.SourcePath))
+		return
+	}
+
+	discType, err := disk.ParseDiscType(req.DiscType)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.DiscName == "" {
+		req.DiscName = "Manual"
+	}
+	if req.TitleName == "" {
+		req.TitleName = filepath.Base(req.SourcePath)
+	}
+
+	item := &encode.QueueItem{
+		ID:         uuid.New().String(),
+		SourcePath: req.SourcePath,
+		DestPath:   req.DestPath,
+		DiscType:   discType,
+		DiscName:   req.DiscName,
+		TitleName:  req.TitleName,
+		Status:     encode.StatusQueued,
+		Progress:   0,
+		CreatedAt:  time.Now(),
+		Priority:   req.Priority,
+	}
+
+	if err := s.queue.Add(item); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, NewItem(item))
+}
+
+func (s *Server) handleGetItem(w http.ResponseWriter, r *http.Request) {
+	item := s.findItem(r.PathValue("id"))
+	if item == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("queue item not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, NewItem(item))
+}
+
+func (s *Server) handleRemoveItem(w http.ResponseWriter, r *http.Request) {
+	item := s.findItem(r.PathValue("id"))
+	if item == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("queue item not found"))
+		return
+	}
+
+	// The running encode has to be stopped by the worker, which then removes it
+	if item.Status == encode.StatusEncoding {
+		if err := s.ctrl.ControlEncode(item.ID, encode.WorkerDelete); err != nil {
+			writeError(w, errorStatus(err), err)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if err := s.queue.Remove(item.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRetry(w http.ResponseWriter, r *http.Request) {
+	if err := s.queue.RetryFailed(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleClear(w http.ResponseWriter, r *http.Request) {
+	if err := s.queue.ClearCompleted(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleScanMissing(w http.ResponseWriter, r *http.Request) {
+	if err := s.ctrl.ScanMissing(); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleEncodeControl controls every running encode
+func (s *Server) handleEncodeControl(w http.ResponseWriter, r *http.Request) {
+	ctrl, err := parseControl(r.PathValue("action"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if err := s.ctrl.ControlEncode("", ctrl); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleItemControl controls the encode of a single queue item
+func (s *Server) handleItemControl(w http.ResponseWriter, r *http.Request) {
+	ctrl, err := parseControl(r.PathValue("action"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	item := s.findItem(r.PathValue("id"))
+	if item == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("queue item not found"))
+		return
+	}
+	if item.Status != encode.StatusEncoding {
+		err := fmt.Errorf("%w: %s is %s", ErrNotEncoding, item.ID, item.Status)
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	if err := s.ctrl.ControlEncode(item.ID, ctrl); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleMoveItem moves a queued item up, down or to the top of the queue
+func (s *Server) handleMoveItem(w http.ResponseWriter, r *http.Request) {
+	item := s.findItem(r.PathValue("id"))
+	if item == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("queue item not found"))
+		return
+	}
+
+	var err error
+	switch r.PathValue("direction") {
+	case "up":
+		err = s.queue.MoveUp(item.ID)
+	case "down":
+		err = s.queue.MoveDown(item.ID)
+	case "top":
+		err = s.queue.MoveToTop(item.ID)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown direction %q (use up, down or top)", r.PathValue("direction")))
+		return
+	}
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewItem(item))
+}
+
+func (s *Server) handleSetPriority(w http.ResponseWriter, r *http.Request) {
+	var req PriorityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	item := s.findItem(r.PathValue("id"))
+	if item == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("queue item not found"))
+		return
+	}
+
+	if err := s.queue.SetPriority(item.ID, req.Priority); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewItem(item))
+}
+
+// parseControl maps an action path segment to a worker command
+func parseControl(action string) (encode.WorkerControl, error) {
+	switch action {
+	case "pause":
+		return encode.WorkerPause, nil
+	case "resume":
+		return encode.WorkerResume, nil
+	case "stop":
+		return encode.WorkerStop, nil
+	case "delete":
+		return encode.WorkerDelete, nil
+	default:
+		return 0, fmt.Errorf("unknown action %q (use pause, resume, stop or delete)", action)
+	}
+}
+
+// handleGetSeries returns the active series session, or null
+func (s *Server) handleGetSeries(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.series.Current())
+}
+
+func (s *Server) handleStartSeries(w http.ResponseWriter, r *http.Request) {
+	var req SeriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	session, err := s.series.Start(req.Show, req.Season, req.Episode)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, session)
+}
+
+func (s *Server) handleEndSeries(w http.ResponseWriter, r *http.Request) {
+	if err := s.series.End(); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findItem looks up a queue item by ID
+func (s *Server) findItem(id string) *encode.QueueItem {
+	for _, item := range s.queue.GetAll() {
+		if item.ID == id {
+			return item
+		}
+	}
+	return nil
+}
+
+// errorStatus maps controller errors to HTTP status codes
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrUnknownDrive), errors.Is(err, series.ErrNoSession):
+		return http.StatusNotFound
+	case errors.Is(err, ErrBusy), errors.Is(err, ErrNoDisc), errors.Is(err, ErrNotRipping), errors.Is(err, ErrNotEncoding), errors.Is(err, encode.ErrNotQueued):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -4,60 +4,206 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/google/uuid"
+	"github.com/mmzim/mkvauto/internal/api"
 	"github.com/mmzim/mkvauto/internal/config"
 	"github.com/mmzim/mkvauto/internal/disk"
 	"github.com/mmzim/mkvauto/internal/encode"
+	"github.com/mmzim/mkvauto/internal/history"
 	"github.com/mmzim/mkvauto/internal/makemkv"
+	"github.com/mmzim/mkvauto/internal/metadata"
 	"github.com/mmzim/mkvauto/internal/notify"
+	"github.com/mmzim/mkvauto/internal/ripjob"
+	"github.com/mmzim/mkvauto/internal/schedule"
+	"github.com/mmzim/mkvauto/internal/series"
+	"github.com/mmzim/mkvauto/internal/throttle"
 	"github.com/mmzim/mkvauto/internal/ui"
 )
 
 type App struct {
-	config           *config.Config
-	queue            *encode.Queue
-	makemkvClient    *makemkv.Client
-	diskDetector     *disk.Detector
-	notifier         *notify.DiscordWebhook
-	workerControl    chan encode.WorkerControl
+	config        *config.Config
+	queue         *encode.Queue
+	schedule      *schedule.Schedule // nil encodes at any time
+	throttle      *throttle.Throttle // nil never throttles encodes
+	makemkvClient *makemkv.Client
+	drives        []*driveRunner
+	notifier      *notify.Dispatcher
+	metadata      metadata.Provider // nil when lookups are disabled
+	series        *series.Store
+	ripJobs       *ripjob.Store
+	history       *history.Store
+	policy        *makemkv.Policy
+	workerControl chan encode.ItemControl
+	scanRequestCh chan struct{}
+	toolOutput    chan string // Raw makemkvcon and HandBrake output, logged at debug level
+	tracker       *stateTracker
+	events        *api.Broker
+	sink          Sink // Set by start, for state changes made through the control API
+	logFile       *os.File
+	headless      bool // No UI available for interactive prompts
+}
+
+// driveRunner holds the detector and rip pipeline channels for one drive
+type driveRunner struct {
+	config           config.DriveConfig
+	detector         *disk.Detector
 	titleSelectionCh chan []int
+	metadataCh       chan int
 	cancelRipCh      chan struct{}
-	scanRequestCh    chan struct{}
-	program          *tea.Program
-	logFile          *os.File
+	pauseRipCh       chan bool     // true pauses the running rip, false resumes it
+	knownDiscCh      chan bool     // true rips a previously ripped disc again
+	trayCh           chan bool     // true opens the tray, false closes it
+	ripRequestCh     chan struct{} // Process the disc already in the drive
 }
 
-func New(cfg *config.Config) *App {
-	// Create queue state directory
-	homeDir, _ := os.UserHomeDir()
-	stateDir := filepath.Join(homeDir, ".mkvauto")
-	statePath := filepath.Join(stateDir, "queue.json")
+func New(cfg *config.Config) (*App, error) {
+	source, err := disk.NewSource(cfg.Detection)
+	if err != nil {
+		return nil, fmt.Errorf("detection: %w", err)
+	}
 
-	return &App{
-		config:           cfg,
-		queue:            encode.NewQueue(statePath),
-		makemkvClient:    makemkv.NewClient(cfg.MakeMKV.BinaryPath),
-		diskDetector:     disk.NewDetector(cfg.Drive.Path),
-		notifier:         notify.NewDiscordWebhook(cfg.DiscordWebhook),
-		workerControl:    make(chan encode.WorkerControl, 10),
-		titleSelectionCh: make(chan []int, 1),
-		cancelRipCh:      make(chan struct{}, 1),
-		scanRequestCh:    make(chan struct{}, 1),
+	drives := make([]*driveRunner, len(cfg.Drives))
+	for i, d := range cfg.Drives {
+		drives[i] = &driveRunner{
+			config:           d,
+			detector:         disk.NewDetector(d.Path, source),
+			titleSelectionCh: make(chan []int, 1),
+			metadataCh:       make(chan int, 1),
+			cancelRipCh:      make(chan struct{}, 1),
+			pauseRipCh:       make(chan bool, 1),
+			knownDiscCh:      make(chan bool, 1),
+			trayCh:           make(chan bool, 1),
+			ripRequestCh:     make(chan struct{}, 1),
+		}
+	}
+
+	destinations, err := notify.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := encode.NewQueue(QueuePath())
+	queue.SetPolicy(encode.Policy(cfg.Queue.Policy))
+
+	encodeSchedule, err := schedule.FromConfig(cfg.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	encodeThrottle, err := throttle.FromConfig(cfg.Throttle)
+	if err != nil {
+		return nil, fmt.Errorf("throttle: %w", err)
 	}
+
+	return &App{
+		config:        cfg,
+		queue:         queue,
+		schedule:      encodeSchedule,
+		throttle:      encodeThrottle,
+		series:        series.NewStore(SeriesPath()),
+		ripJobs:       ripjob.NewStore(RipJobsPath()),
+		history:       history.NewStore(HistoryPath()),
+		makemkvClient: makemkv.NewClient(cfg.MakeMKV.BinaryPath),
+		drives:        drives,
+		notifier:      notify.NewDispatcher(destinations, NotifySpoolPath()),
+		workerControl: make(chan encode.ItemControl, 10),
+		scanRequestCh: make(chan struct{}, 1),
+		toolOutput:    make(chan string, 100),
+		tracker:       newStateTracker(drives),
+		events:        api.NewBroker(),
+	}, nil
 }
 
+// Run starts the pipeline with the interactive TUI
 func (a *App) Run() error {
+	cleanup, err := a.prepare()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// Create context for goroutines
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Initialize TUI
+	driveControls := make([]ui.DriveControl, len(a.drives))
+	for i, drv := range a.drives {
+		driveControls[i] = ui.DriveControl{
+			Device:           drv.config.Path,
+			Name:             drv.config.Label(),
+			TitleSelectionCh: drv.titleSelectionCh,
+			MetadataCh:       drv.metadataCh,
+			CancelRipCh:      drv.cancelRipCh,
+			PauseRipCh:       drv.pauseRipCh,
+			KnownDiscCh:      drv.knownDiscCh,
+			TrayCh:           drv.trayCh,
+		}
+	}
+	model := ui.NewModel(a.queue, a.series, a.history, a.workerControl, driveControls, a.config.OutputDir, a.scanRequestCh)
+	program := tea.NewProgram(model, tea.WithAltScreen())
+
+	a.start(ctx, program)
+
+	// Run the TUI
+	if _, err := program.Run(); err != nil {
+		return fmt.Errorf("TUI error: %w", err)
+	}
+
+	return nil
+}
+
+// RunHeadless starts the pipeline without a TUI, writing events to the logger
+// Runs until SIGINT or SIGTERM is received
+func (a *App) RunHeadless(logger *slog.Logger) error {
+	cleanup, err := a.prepare()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a.headless = true
+	a.start(ctx, newLogSink(logger, a.drives))
+
+	logger.Info("mkvauto started", "drives", len(a.drives), "output_dir", a.config.OutputDir)
+	<-ctx.Done()
+	logger.Info("shutting down")
+
+	return nil
+}
+
+// prepare takes the instance lock, loads the queue and opens the log file
+// The returned cleanup function releases everything again
+func (a *App) prepare() (func(), error) {
+	provider, err := metadata.New(a.config.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	a.metadata = provider
+
+	policy, err := makemkv.NewPolicy(a.config.Selection, a.config.Thresholds)
+	if err != nil {
+		return nil, err
+	}
+	a.policy = policy
+
 	// Create lock file to prevent multiple instances
-	homeDir, _ := os.UserHomeDir()
-	lockPath := filepath.Join(homeDir, ".mkvauto", "mkvauto.lock")
+	lockPath := LockPath()
 	os.MkdirAll(filepath.Dir(lockPath), 0755)
 
 	// Try to create lock file
@@ -66,128 +212,327 @@ func (a *App) Run() error {
 		if os.IsExist(err) {
 			// Lock file exists, check if process is still running
 			if isProcessRunning(lockPath) {
-				return fmt.Errorf("another instance of mkvauto is already running (lock file exists: %s)", lockPath)
+				return nil, fmt.Errorf("another instance of mkvauto is already running (lock file exists: %s)", lockPath)
 			}
 			// Stale lock file, remove it and try again
 			os.Remove(lockPath)
 			lockFile, err = os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
 			if err != nil {
-				return fmt.Errorf("failed to create lock file: %w", err)
+				return nil, fmt.Errorf("failed to create lock file: %w", err)
 			}
 		} else {
-			return fmt.Errorf("failed to create lock file: %w", err)
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
 		}
 	}
-	defer func() {
+	releaseLock := func() {
 		lockFile.Close()
 		os.Remove(lockPath)
-	}()
+	}
 
 	// Write PID to lock file
 	fmt.Fprintf(lockFile, "%d\n", os.Getpid())
 
-	// Load queue state from disk
-	if err := a.queue.LoadState(); err != nil {
-		return fmt.Errorf("failed to load queue state: %w", err)
+	// Load queue state from disk, waiting for any CLI invocation that is
+	// still modifying the file without a running instance
+	unlockState, err := encode.LockStateFile(QueuePath())
+	if err != nil {
+		releaseLock()
+		return nil, err
+	}
+	err = a.queue.LoadState()
+	unlockState()
+	if err != nil {
+		releaseLock()
+		return nil, fmt.Errorf("failed to load queue state: %w", err)
+	}
+
+	// Same for the series session
+	unlockSeries, err := encode.LockStateFile(SeriesPath())
+	if err != nil {
+		releaseLock()
+		return nil, err
+	}
+	err = a.series.Load()
+	unlockSeries()
+	if err != nil {
+		releaseLock()
+		return nil, err
+	}
+
+	// Unfinished rips resume when their disc comes back
+	if err := a.ripJobs.Load(); err != nil {
+		releaseLock()
+		return nil, err
 	}
 
 	// Create log file (truncate existing)
-	logPath := filepath.Join(homeDir, ".mkvauto", "mkvauto.log")
+	logPath := filepath.Join(StateDir(), "mkvauto.log")
 	os.MkdirAll(filepath.Dir(logPath), 0755)
 
 	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to open log file: %w", err)
+		releaseLock()
+		return nil, fmt.Errorf("failed to open log file: %w", err)
 	}
-	defer logFile.Close()
 	a.logFile = logFile
 
 	// Write session start marker
 	fmt.Fprintf(logFile, "=== Session started at %s ===\n", time.Now().Format(time.RFC3339))
 
-	// Create context for goroutines
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	return func() {
+		logFile.Close()
+		os.Remove(SocketPath())
+		releaseLock()
+	}, nil
+}
 
-	// Start encoding worker
+// start launches the encode worker, drive pipelines and event forwarding
+func (a *App) start(ctx context.Context, sink Sink) {
+	// Keep track of rip state and stream events for the control API
+	sink = multiSink{a.tracker, &eventSink{broker: a.events}, sink}
+	a.sink = sink
+
+	// Start encoding workers
 	progressCh := make(chan encode.ProgressUpdate, 10)
 	logCh := make(chan string, 100)
-	go a.startEncodingWorker(ctx, progressCh, logCh)
-
-	// Start disk detector
-	diskCh := a.diskDetector.Start(ctx)
-
-	// Initialize TUI
-	model := ui.NewModel(a.queue, a.workerControl, a.titleSelectionCh, a.config.OutputDir, a.cancelRipCh, a.scanRequestCh)
-	a.program = tea.NewProgram(model, tea.WithAltScreen())
+	go a.startEncodingWorkers(ctx, progressCh, logCh)
 
 	// Start background goroutines
-	go a.handleDisks(ctx, diskCh, a.program, logCh)
-	go a.handleEncodeProgress(ctx, progressCh, a.program)
-	go a.handleLogs(ctx, logCh, a.program)
+	go a.startDrives(ctx, sink, logCh)
+	go a.handleEncodeProgress(ctx, progressCh, sink)
+	go a.holdEncodes(ctx, sink, logCh)
+	go a.handleLogs(ctx, logCh, sink)
 	go a.handleScanRequests(ctx, logCh)
+	go a.watchQueueDrained(ctx)
+	go a.watchEncodeResults(ctx, logCh)
+	go a.notifier.Run(ctx, func(line string) { logCh <- line })
+	go a.serveAPI(ctx, logCh)
+}
 
-	// Run the TUI
-	if _, err := a.program.Run(); err != nil {
-		return fmt.Errorf("TUI error: %w", err)
+// startEncodingWorkers runs the configured number of encodes in parallel
+// Without a thread count, the cores are split evenly between the workers
+// so parallel encodes don't fight over them
+func (a *App) startEncodingWorkers(ctx context.Context, progressCh chan<- encode.ProgressUpdate, logCh chan<- string) {
+	workers := max(a.config.HandBrake.Workers, 1)
+	threads := a.config.HandBrake.Threads
+	if threads == 0 && workers > 1 {
+		threads = max(runtime.NumCPU()/workers, 1)
 	}
 
-	return nil
+	handbrakes := make([]*encode.HandBrake, workers)
+	for i := range handbrakes {
+		handbrakes[i] = encode.NewHandBrake(a.config, threads)
+	}
+	if workers > 1 {
+		logCh <- fmt.Sprintf("Starting %d encode workers with %d threads each", workers, threads)
+	}
+
+	encode.NewPool(a.queue, handbrakes, progressCh, a.workerControl, logCh, a.toolOutput).Run(ctx)
 }
 
-func (a *App) startEncodingWorker(ctx context.Context, progressCh chan<- encode.ProgressUpdate, logCh chan<- string) {
-	handbrake := encode.NewHandBrake(a.config)
-	worker := encode.NewWorker(a.queue, handbrake, progressCh, a.workerControl, logCh)
-	worker.Run(ctx)
+// holdEncodes keeps the encode workers to the encode windows and backs
+// them off while the machine is too loaded or too hot, checking at least
+// once a minute and right when a window opens or closes
+func (a *App) holdEncodes(ctx context.Context, sink Sink, logCh chan<- string) {
+	if a.schedule == nil && a.throttle == nil {
+		return
+	}
+
+	state := encode.WorkerRelease
+	lowPriority := false
+	sampleFailed := false
+	var last *ui.EncodeHeldMsg
+	for {
+		now := time.Now()
+		msg := ui.EncodeHeldMsg{Next: a.schedule.Describe(now)}
+		want := encode.WorkerRelease
+		var reasons []string
+		if !a.schedule.Open(now) {
+			reasons = append(reasons, "outside the encode window")
+			want = encode.WorkerHold
+			if a.config.Schedule.PauseRunning {
+				want = encode.WorkerSuspend
+			}
+		}
+
+		if a.throttle != nil {
+			throttled, reason, err := a.throttle.Check()
+			if err != nil && !sampleFailed {
+				logCh <- fmt.Sprintf("Could not read system metrics for throttling: %v", err)
+			}
+			sampleFailed = err != nil
+
+			switch throttled {
+			case throttle.StateLowPriority:
+				msg.Throttle = reason
+			case throttle.StatePause:
+				reasons = append(reasons, reason)
+				want = encode.WorkerSuspend
+			}
+			if low := msg.Throttle != ""; low != lowPriority {
+				if low {
+					a.workerControl <- encode.ItemControl{Control: encode.WorkerLowPriority}
+					logCh <- fmt.Sprintf("Lowering encoder priority: %s", reason)
+				} else {
+					a.workerControl <- encode.ItemControl{Control: encode.WorkerNormalPriority}
+					logCh <- "System load back to normal, restoring encoder priority"
+				}
+				lowPriority = low
+			}
+		}
+		msg.Reason = strings.Join(reasons, ", ")
+
+		if want != state {
+			a.workerControl <- encode.ItemControl{Control: want}
+			switch want {
+			case encode.WorkerRelease:
+				logCh <- "Encodes may run again, starting encodes"
+			case encode.WorkerHold:
+				logCh <- fmt.Sprintf("Holding encodes: %s", msg.Reason)
+			case encode.WorkerSuspend:
+				logCh <- fmt.Sprintf("Pausing encodes: %s", msg.Reason)
+			}
+			state = want
+		}
+		if last == nil || msg != *last {
+			sink.Send(msg)
+			last = &msg
+		}
+
+		wait := time.Minute
+		if next := a.schedule.Next(now); !next.IsZero() && next.Sub(now) < wait {
+			wait = next.Sub(now)
+		}
+		if a.throttle != nil {
+			wait = min(wait, a.throttle.Interval())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
 }
 
-func (a *App) handleDisks(ctx context.Context, diskCh <-chan disk.DetectedDisc, program *tea.Program, logCh chan<- string) {
+// startDrives maps drives to MakeMKV disc indexes and starts a detector per drive
+func (a *App) startDrives(ctx context.Context, sink Sink, logCh chan<- string) {
+	var unresolved []string
+	for _, drv := range a.drives {
+		if drv.config.DiscIndex != nil {
+			a.makemkvClient.SetDiscIndex(drv.config.Path, *drv.config.DiscIndex)
+			continue
+		}
+		unresolved = append(unresolved, drv.config.Path)
+	}
+
+	if len(unresolved) > 0 {
+		resolved, err := a.makemkvClient.ResolveDrives(ctx, unresolved)
+		if err != nil {
+			logCh <- fmt.Sprintf("Could not list MakeMKV drives, addressing devices directly: %v", err)
+		}
+		for _, device := range unresolved {
+			if index, ok := resolved[device]; ok {
+				logCh <- fmt.Sprintf("Drive %s is MakeMKV disc:%d", device, index)
+			} else if err == nil {
+				logCh <- fmt.Sprintf("Drive %s not reported by MakeMKV, using dev:%s", device, device)
+			}
+		}
+	}
+
+	for _, drv := range a.drives {
+		eventCh, err := drv.detector.Start(ctx)
+		if err != nil {
+			logCh <- fmt.Sprintf("Disc detection on %s failed: %v", drv.config.Path, err)
+			continue
+		}
+		go a.handleDisks(ctx, drv, eventCh, sink, logCh)
+	}
+}
+
+func (a *App) handleDisks(ctx context.Context, drv *driveRunner, eventCh <-chan disk.Event, sink Sink, logCh chan<- string) {
+	seen := false // Whether the drive was readable before
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case disc := <-diskCh:
-			// Process disc in a goroutine (non-blocking)
-			go a.processDisc(ctx, disc, program, logCh)
+		case event, ok := <-eventCh:
+			if !ok {
+				return
+			}
+			sink.Send(ui.MediaChangedMsg{Drive: event.Device, State: event.State, Err: event.Err})
+			switch event.State {
+			case disk.StateMissing:
+				logCh <- fmt.Sprintf("Drive %s not found: %v", event.Device, event.Err)
+				// A drive missing from the start is only logged
+				if seen {
+					a.sendNotification(notify.Error("Drive", fmt.Sprintf("%s disappeared (%v)", drv.config.Label(), event.Err)))
+				}
+			case disk.StateError:
+				logCh <- fmt.Sprintf("Cannot access drive %s: %v", event.Device, event.Err)
+			case disk.StateDisc:
+				// Process disc in a goroutine (non-blocking)
+				go a.processDisc(ctx, drv, disk.DetectedDisc{Device: event.Device}, sink, logCh)
+			}
+			seen = event.Err == nil
+		case <-drv.ripRequestCh:
+			go a.processDisc(ctx, drv, disk.DetectedDisc{Device: drv.config.Path}, sink, logCh)
+		case open := <-drv.trayCh:
+			go a.moveTray(drv.config.Path, open, logCh)
 		}
 	}
 }
 
-func (a *App) handleLogs(ctx context.Context, logCh <-chan string, program *tea.Program) {
+// moveTray opens or closes the tray of a drive
+func (a *App) moveTray(device string, open bool, logCh chan<- string) {
+	var err error
+	if open {
+		err = disk.Eject(device)
+	} else {
+		err = disk.Close(device)
+	}
+	if err != nil {
+		logCh <- fmt.Sprintf("Failed to move the tray of %s: %v", device, err)
+	}
+}
+
+// writeLog shows a log line and appends it to the log file
+func (a *App) writeLog(msg ui.LogMsg, sink Sink) {
+	sink.Send(msg)
+	if a.logFile != nil {
+		fmt.Fprintln(a.logFile, msg.Line)
+	}
+}
+
+func (a *App) handleLogs(ctx context.Context, logCh <-chan string, sink Sink) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case logLine := <-logCh:
-			// Send to TUI
-			program.Send(ui.LogMsg{Line: logLine})
-
-			// Write to log file
-			if a.logFile != nil {
-				fmt.Fprintln(a.logFile, logLine)
-			}
+			a.writeLog(ui.LogMsg{Line: logLine}, sink)
+		case logLine := <-a.toolOutput:
+			a.writeLog(ui.LogMsg{Line: logLine, Output: true}, sink)
 		}
 	}
 }
 
-func (a *App) handleEncodeProgress(ctx context.Context, progressCh <-chan encode.ProgressUpdate, program *tea.Program) {
+func (a *App) handleEncodeProgress(ctx context.Context, progressCh <-chan encode.ProgressUpdate, sink Sink) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case update := <-progressCh:
-			program.Send(ui.EncodeProgressMsg{
+			sink.Send(ui.EncodeProgressMsg{
 				ItemID:   update.ItemID,
 				Progress: update.Progress,
 			})
 
 			// Check if encode just completed
 			if update.Progress >= 100.0 {
-				item := a.queue.GetCurrent()
+				item := a.queue.Get(update.ItemID)
 				if item != nil {
-					// Send Discord notification
-					a.notifier.SendEncodeComplete(item.TitleName, item.DiscType.String())
-					program.Send(ui.EncodeCompleteMsg{ItemID: item.ID})
+					a.sendNotification(notify.EncodeComplete(item.TitleName, item.DiscType.String()))
+					sink.Send(ui.EncodeCompleteMsg{ItemID: item.ID})
 				}
 			}
 		}
@@ -206,6 +551,110 @@ func (a *App) handleScanRequests(ctx context.Context, logCh chan<- string) {
 	}
 }
 
+// watchQueueDrained sends a notification when the encode queue runs out of work
+func (a *App) watchQueueDrained(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	var busySince time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		active := false
+		for _, item := range a.queue.GetAll() {
+			if item.Status == encode.StatusQueued || item.Status == encode.StatusEncoding {
+				active = true
+				break
+			}
+		}
+
+		if active {
+			if busySince.IsZero() {
+				busySince = time.Now()
+			}
+			continue
+		}
+		if busySince.IsZero() {
+			continue
+		}
+
+		// Count only the items that finished during this run
+		completed, failed := 0, 0
+		for _, item := range a.queue.GetAll() {
+			if item.CompletedAt == nil || item.CompletedAt.Before(busySince) {
+				continue
+			}
+			switch item.Status {
+			case encode.StatusComplete:
+				completed++
+			case encode.StatusFailed:
+				failed++
+			}
+		}
+		busySince = time.Time{}
+
+		if completed+failed > 0 {
+			a.sendNotification(notify.QueueDrained(completed, failed))
+		}
+	}
+}
+
+// watchEncodeResults records finished encodes in the rip history
+func (a *App) watchEncodeResults(ctx context.Context, logCh chan<- string) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	// Status last recorded per queue item, so a retried item is recorded again
+	recorded := make(map[string]encode.ItemStatus)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var results []history.EncodeResult
+		var finished []*encode.QueueItem
+		for _, item := range a.queue.GetAll() {
+			if item.Status != encode.StatusComplete && item.Status != encode.StatusFailed {
+				continue
+			}
+			if status, ok := recorded[item.ID]; ok && status == item.Status {
+				continue
+			}
+
+			result := history.EncodeResult{
+				RawPath:     item.SourcePath,
+				EncodedPath: item.DestPath,
+				Failed:      item.Status == encode.StatusFailed,
+				Error:       item.Error,
+			}
+			if info, err := os.Stat(item.DestPath); err == nil && !result.Failed {
+				result.EncodedSize = info.Size()
+			}
+			results = append(results, result)
+			finished = append(finished, item)
+		}
+
+		if err := a.history.SetEncodes(results); err != nil {
+			logCh <- fmt.Sprintf("Failed to save rip history: %v", err)
+			continue
+		}
+		for _, item := range finished {
+			recorded[item.ID] = item.Status
+		}
+	}
+}
+
+// sendNotification queues an event for background delivery
+func (a *App) sendNotification(event notify.Event) {
+	a.notifier.Notify(context.Background(), event)
+}
+
 func formatDuration(d time.Duration) string {
 	hours := int(d.Hours())
 	minutes := int(d.Minutes()) % 60
@@ -226,34 +675,62 @@ func formatSize(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
-func (a *App) processDisc(ctx context.Context, disc disk.DetectedDisc, program *tea.Program, logCh chan<- string) {
+func (a *App) processDisc(ctx context.Context, drv *driveRunner, disc disk.DetectedDisc, sink Sink, logCh chan<- string) {
 	// Create cancellable context for this disc processing
 	ripCtx, cancelRip := context.WithCancel(ctx)
 	defer cancelRip()
 
 	// Track if manually cancelled
-	manuallyCancelled := false
+	// Set by the cancel watcher goroutine below
+	var manuallyCancelled atomic.Bool
+
+	// Drop pause requests left over from the previous disc, and never leave
+	// the next disc starting paused
+	select {
+	case <-drv.pauseRipCh:
+	default:
+	}
+	defer a.makemkvClient.Resume(disc.Device)
 
-	// Monitor for cancel requests
+	// Monitor for cancel and pause requests
 	go func() {
-		select {
-		case <-a.cancelRipCh:
-			manuallyCancelled = true
-			cancelRip()
-			disk.Eject(disc.Device)
-		case <-ripCtx.Done():
+		for {
+			select {
+			case <-drv.cancelRipCh:
+				manuallyCancelled.Store(true)
+				// A suspended makemkvcon still dies on the kill from cancelRip
+				cancelRip()
+				disk.Eject(disc.Device)
+				sink.Send(ui.RipCancelledMsg{Drive: disc.Device})
+				return
+			case pause := <-drv.pauseRipCh:
+				var err error
+				if pause {
+					err = a.makemkvClient.Pause(disc.Device)
+				} else {
+					err = a.makemkvClient.Resume(disc.Device)
+				}
+				if err != nil {
+					logCh <- fmt.Sprintf("Failed to pause/resume rip on %s: %v", disc.Device, err)
+					continue
+				}
+				sink.Send(ui.RipPausedMsg{Drive: disc.Device, Paused: pause})
+			case <-ripCtx.Done():
+				return
+			}
 		}
 	}()
 
 	// Notify TUI
-	program.Send(ui.DiskInsertedMsg{})
+	sink.Send(ui.DiskInsertedMsg{Drive: disc.Device})
+	a.sendNotification(notify.DiscInserted(drv.config.Label()))
 
 	// Create status channel for scan updates
 	scanStatusCh := make(chan string, 10)
 	go func() {
 		for status := range scanStatusCh {
 			// Prefix with "Scan: " to make it clear this is the initial scan
-			program.Send(ui.StatusUpdateMsg{Status: "Scan: " + status})
+			sink.Send(ui.StatusUpdateMsg{Drive: disc.Device, Status: "Scan: " + status})
 		}
 	}()
 
@@ -267,10 +744,10 @@ func (a *App) processDisc(ctx context.Context, disc disk.DetectedDisc, program *
 			return
 		}
 
-		program.Send(ui.ErrorMsg{Err: fmt.Errorf("scan failed: %w", err)})
-		// Don't send Discord notification if manually cancelled
-		if !manuallyCancelled {
-			a.notifier.SendError("Disc Scan", err.Error())
+		sink.Send(ui.ErrorMsg{Drive: disc.Device, Err: fmt.Errorf("scan failed: %w", err)})
+		// Don't send notification if manually cancelled
+		if !manuallyCancelled.Load() {
+			a.sendNotification(notify.Error("Disc Scan", err.Error()))
 		}
 		return
 	}
@@ -279,95 +756,95 @@ func (a *App) processDisc(ctx context.Context, disc disk.DetectedDisc, program *
 	disc.Name = disk.SanitizeFilename(scanResult.DiscName)
 	disc.DiscType = disk.DetectDiscTypeFromInfo(scanResult.DiscType)
 
-	program.Send(ui.ScanCompleteMsg{
+	sink.Send(ui.ScanCompleteMsg{
+		Drive: disc.Device,
 		Info: ui.DiskInfo{
 			Name:     scanResult.DiscName,
 			DiscType: disc.DiscType.String(),
 		},
 	})
 
-	// Select titles based on duration logic
-	movieThreshold := time.Duration(a.config.Thresholds.MovieMinMinutes) * time.Minute
-	episodeThreshold := time.Duration(a.config.Thresholds.EpisodeMinMinutes) * time.Minute
-	selectedTitles := makemkv.SelectTitles(scanResult.Titles, movieThreshold, episodeThreshold)
+	// The volume ID tells apart discs MakeMKV reports identically
+	if volumeID, err := disk.VolumeID(disc.Device); err == nil {
+		scanResult.VolumeID = volumeID
+	} else {
+		logCh <- fmt.Sprintf("Could not read volume ID of %s: %v", disc.Device, err)
+	}
 
-	// If no titles matched, show manual selection UI
-	if len(selectedTitles) == 0 {
-		// Convert titles to UI format
-		uiTitles := make([]ui.Title, len(scanResult.Titles))
-		for i, t := range scanResult.Titles {
-			uiTitles[i] = ui.Title{
-				ID:       t.ID,
-				Name:     t.Name,
-				Duration: formatDuration(t.Duration),
-				Size:     formatSize(t.Size),
-				Selected: false,
-			}
+	// A disc seen before resumes its unfinished rip; anything else is planned afresh
+	fingerprint := scanResult.Fingerprint()
+	job := a.ripJobs.Get(fingerprint)
+	if job != nil && !makemkv.SameVolume(job.VolumeID, scanResult.VolumeID) {
+		// Another pressing with the same layout
+		job = nil
+	}
+	if job != nil {
+		logCh <- fmt.Sprintf("%s: resuming earlier rip, %d of %d titles already ripped",
+			scanResult.DiscName, job.Ripped(), len(job.Titles))
+	} else {
+		if !a.confirmKnownDisc(ripCtx, drv, disc.Device, scanResult, sink, logCh) {
+			return
 		}
-
-		// Show title selection UI
-		program.Send(ui.ShowTitleSelectionMsg{Titles: uiTitles})
-
-		// Wait for user selection
-		selectedIDs := <-a.titleSelectionCh
-
-		if len(selectedIDs) == 0 {
-			program.Send(ui.ErrorMsg{Err: fmt.Errorf("no titles selected")})
-			disk.Eject(disc.Device)
+		job = a.planRip(ripCtx, drv, disc, scanResult, sink, logCh)
+		if job == nil {
 			return
 		}
-
-		// Build selectedTitles from IDs
-		selectedTitles = nil
-		for _, id := range selectedIDs {
-			for _, t := range scanResult.Titles {
-				if t.ID == id {
-					selectedTitles = append(selectedTitles, t)
-					break
-				}
-			}
+		job.HistoryID = a.addHistory(job, scanResult, drv, logCh)
+		if err := a.ripJobs.Put(job); err != nil {
+			logCh <- fmt.Sprintf("Failed to save rip job: %v", err)
 		}
 	}
 
-	// Create disc folder (no timestamp - will reuse folder for same disc)
-	discFolder := filepath.Join(a.config.OutputDir, disc.Name)
+	discFolder := filepath.Join(a.config.OutputDir, job.Folder)
 	rawFolder := filepath.Join(discFolder, "raw")
 	encodedFolder := filepath.Join(discFolder, "encoded")
 
-	// Create directories (will reuse if already exists)
-	if err := os.MkdirAll(rawFolder, 0755); err != nil {
-		program.Send(ui.ErrorMsg{Err: fmt.Errorf("failed to create output directory: %w", err)})
-		disk.Eject(disc.Device)
-		return
+	// Named movies and episodes are encoded straight into their folder
+	outputFolders := []string{rawFolder}
+	if !job.Named {
+		outputFolders = append(outputFolders, encodedFolder)
 	}
-	if err := os.MkdirAll(encodedFolder, 0755); err != nil {
-		program.Send(ui.ErrorMsg{Err: fmt.Errorf("failed to create output directory: %w", err)})
-		disk.Eject(disc.Device)
-		return
+
+	// Create directories (will reuse if already exists)
+	for _, folder := range outputFolders {
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			sink.Send(ui.ErrorMsg{Drive: disc.Device, Err: fmt.Errorf("failed to create output directory: %w", err)})
+			disk.Eject(disc.Device)
+			return
+		}
 	}
 
 	// Rip each selected title
-	for i, title := range selectedTitles {
+	for i, title := range job.Titles {
+		// Titles ripped before a crash or cancel only need to be queued
+		if title.Status == ripjob.StatusRipped {
+			if _, err := os.Stat(title.RawPath); err == nil {
+				logCh <- fmt.Sprintf("Title %d already ripped to %s", title.ID, title.RawPath)
+				a.queueRip(title, disc.DiscType, scanResult.DiscName)
+				continue
+			}
+			logCh <- fmt.Sprintf("Ripped file %s is gone, ripping title %d again", title.RawPath, title.ID)
+		}
+
 		// Notify that we're starting to rip this title
-		program.Send(ui.StatusUpdateMsg{Status: fmt.Sprintf("Preparing to rip title %d of %d...", i+1, len(selectedTitles))})
+		sink.Send(ui.StatusUpdateMsg{Drive: disc.Device, Status: fmt.Sprintf("Preparing to rip title %d of %d...", i+1, len(job.Titles))})
 
-		program.Send(ui.RipProgressMsg{
+		sink.Send(ui.RipProgressMsg{
+			Drive:        disc.Device,
 			Progress:     0,
 			CurrentTitle: i + 1,
-			TotalTitles:  len(selectedTitles),
+			TotalTitles:  len(job.Titles),
 		})
 
-		// Note: We don't know the exact filename MakeMKV will create yet,
-		// it uses disc name + _t## format, so we'll find it after ripping
-
 		// Rip with progress updates
 		ripProgressCh := make(chan float64, 10)
 		go func() {
 			for progress := range ripProgressCh {
-				program.Send(ui.RipProgressMsg{
+				sink.Send(ui.RipProgressMsg{
+					Drive:        disc.Device,
 					Progress:     progress,
 					CurrentTitle: i + 1,
-					TotalTitles:  len(selectedTitles),
+					TotalTitles:  len(job.Titles),
 				})
 			}
 		}()
@@ -380,139 +857,600 @@ func (a *App) processDisc(ctx context.Context, disc disk.DetectedDisc, program *
 				if strings.HasPrefix(line, "STATUS: ") {
 					status := strings.TrimPrefix(line, "STATUS: ")
 					// Prefix with "Rip: " to distinguish from scan phase
-					program.Send(ui.StatusUpdateMsg{Status: "Rip: " + status})
+					sink.Send(ui.StatusUpdateMsg{Drive: disc.Device, Status: "Rip: " + status})
 				}
-				// Also send to main log channel
-				logCh <- line
+				// Also send to the tool output log
+				a.toolOutput <- line
 			}
 		}()
 
-		err := a.makemkvClient.RipTitle(ripCtx, disc.Device, title.ID, rawFolder, ripProgressCh, ripLogCh)
+		actualRawPath, err := a.makemkvClient.RipTitle(ripCtx, disc.Device, title.ID, rawFolder, ripProgressCh, ripLogCh)
 		close(ripProgressCh)
 		close(ripLogCh)
 
 		if err != nil {
-			program.Send(ui.ErrorMsg{Err: fmt.Errorf("rip failed: %w", err)})
-			// Don't send Discord notification if manually cancelled
-			if !manuallyCancelled {
-				a.notifier.SendError("Disc Rip", err.Error())
+			sink.Send(ui.ErrorMsg{Drive: disc.Device, Err: fmt.Errorf("rip failed: %w", err)})
+			// Don't send notification if manually cancelled
+			if !manuallyCancelled.Load() {
+				a.sendNotification(notify.Error("Disc Rip", err.Error()))
 			}
-			continue
-		}
-
-		// Find the actual file that MakeMKV created (it uses its own naming scheme)
-		actualRawPath, err := findNewestMKVFile(rawFolder)
-		if err != nil {
-			program.Send(ui.ErrorMsg{Err: fmt.Errorf("could not find ripped file: %w", err)})
+			if job.Named && !job.Movie {
+				logCh <- fmt.Sprintf("%s was not ripped, insert the disc again to retry it", title.FileName)
+			}
+			title.Status = ripjob.StatusFailed
+			a.saveRipTitle(job, i, title, err, logCh)
 			continue
 		}
 
 		// Use the actual filename for the encoded output
 		actualFilename := filepath.Base(actualRawPath)
 		actualEncodedPath := filepath.Join(encodedFolder, actualFilename)
+		titleName := title.Name
+
+		// Rename to "Show - S01E05.mkv" or "Movie (Year).mkv"; other movie titles become extras
+		if job.Named {
+			name := title.FileName
+			if name == "" {
+				name = fmt.Sprintf("%s - %s-other", job.Folder, strings.TrimSuffix(actualFilename, filepath.Ext(actualFilename)))
+			}
+			namedRawPath := filepath.Join(rawFolder, name+".mkv")
+			if err := renameNew(actualRawPath, namedRawPath); err != nil {
+				// The rip is fine, so it's kept and encoded under its MakeMKV name
+				sink.Send(ui.ErrorMsg{Drive: disc.Device, Err: fmt.Errorf("failed to rename ripped file, keeping %s: %w", actualFilename, err)})
+			} else {
+				actualRawPath = namedRawPath
+				actualEncodedPath = filepath.Join(discFolder, name+".mkv")
+				titleName = name
+			}
+		}
 
 		// Add to encoding queue with actual file paths
-		queueItem := &encode.QueueItem{
-			ID:         uuid.New().String(),
-			SourcePath: actualRawPath,
-			DestPath:   actualEncodedPath,
-			DiscType:   disc.DiscType,
-			DiscName:   scanResult.DiscName,
-			TitleName:  title.Name,
-			Status:     encode.StatusQueued,
-			Progress:   0,
-			CreatedAt:  time.Now(),
+		title.Status = ripjob.StatusRipped
+		title.RawPath = actualRawPath
+		title.EncodedPath = actualEncodedPath
+		title.Name = titleName
+		a.queueRip(title, disc.DiscType, scanResult.DiscName)
+		a.saveRipTitle(job, i, title, nil, logCh)
+	}
+	a.finishHistory(job, manuallyCancelled.Load() || ripCtx.Err() != nil, logCh)
+
+	// Finished jobs are forgotten; unfinished ones resume on reinsertion
+	// unless the rip was cancelled before anything was ripped
+	if job.Done() || (manuallyCancelled.Load() && job.Ripped() == 0) {
+		if err := a.ripJobs.Remove(fingerprint); err != nil {
+			logCh <- fmt.Sprintf("Failed to remove rip job: %v", err)
 		}
-		a.queue.Add(queueItem)
 	}
 
 	// Check if manually cancelled before sending completion
-	if !manuallyCancelled && ripCtx.Err() == nil {
+	if !manuallyCancelled.Load() && ripCtx.Err() == nil {
 		// Send completion notification
-		program.Send(ui.RipCompleteMsg{})
-		a.notifier.SendRipComplete(scanResult.DiscName, len(selectedTitles), disc.DiscType.String())
+		sink.Send(ui.RipCompleteMsg{Drive: disc.Device})
+		a.sendNotification(notify.RipComplete(scanResult.DiscName, len(job.Titles), disc.DiscType.String()))
 	}
 
 	// Eject disc
 	disk.Eject(disc.Device)
 }
 
-// isProcessRunning checks if the process in the lock file is still running
-func isProcessRunning(lockPath string) bool {
-	data, err := ioutil.ReadFile(lockPath)
-	if err != nil {
-		return false
+// planRip selects the titles of a freshly scanned disc and decides how their
+// output is named. Returns nil if the disc was rejected, ejected or cancelled.
+func (a *App) planRip(ctx context.Context, drv *driveRunner, disc disk.DetectedDisc, scanResult *makemkv.ScanResult, sink Sink, logCh chan<- string) *ripjob.Job {
+	// Select titles based on duration logic
+	movieThreshold := time.Duration(a.config.Thresholds.MovieMinMinutes) * time.Minute
+	episodeThreshold := time.Duration(a.config.Thresholds.EpisodeMinMinutes) * time.Minute
+	session := a.series.Current()
+
+	// Drop duplicate angles and decoy playlists before selecting
+	ranking := makemkv.RankTitles(scanResult.Titles)
+	for _, reason := range ranking.Reasons {
+		logCh <- fmt.Sprintf("%s: %s", scanResult.DiscName, reason)
 	}
 
-	pidStr := strings.TrimSpace(string(data))
-	pid, err := strconv.Atoi(pidStr)
+	// Configured rules come first, then the duration heuristic
+	var selectedTitles []makemkv.Title
+	if sel, ok := a.policy.Select(scanResult.DiscName, disc.DiscType.String(), ranking.Titles); ok {
+		logCh <- fmt.Sprintf("%s: selection rule %q matched (%s)", scanResult.DiscName, sel.Rule, sel.Action)
+		selectedTitles = sel.Titles
+	} else if session != nil {
+		selectedTitles = makemkv.SelectEpisodes(ranking.Titles, movieThreshold, episodeThreshold)
+	} else {
+		selectedTitles = makemkv.SelectTitles(ranking.Titles, movieThreshold, episodeThreshold)
+	}
+	if ranking.Obfuscated && len(selectedTitles) == 1 {
+		logCh <- fmt.Sprintf("%s: obfuscated disc, selected title %d as the main feature", scanResult.DiscName, selectedTitles[0].ID)
+	}
+
+	// Without a UI there's nobody to pick titles, so give up on this disc
+	if len(selectedTitles) == 0 && a.headless {
+		err := fmt.Errorf("no titles matched automatic selection criteria")
+		sink.Send(ui.ErrorMsg{Drive: disc.Device, Err: err})
+		a.sendNotification(notify.Error("Title Selection", fmt.Sprintf("%s: %v", scanResult.DiscName, err)))
+		disk.Eject(disc.Device)
+		return nil
+	}
+
+	// If no titles matched, show manual selection UI
+	// With selection.confirm the automatic selection is offered pre-checked too
+	auto := len(selectedTitles) > 0
+	if !auto || (a.config.Selection.Confirm && !a.headless) {
+		preselected := make(map[int]bool, len(selectedTitles))
+		for _, t := range selectedTitles {
+			preselected[t.ID] = true
+		}
+
+		// Convert titles to UI format
+		uiTitles := make([]ui.Title, len(scanResult.Titles))
+		for i, t := range scanResult.Titles {
+			info := titleInfo(t)
+			if kept, ok := ranking.Dropped[t.ID]; ok {
+				info = strings.TrimSpace(fmt.Sprintf("%s (same as title %d)", info, kept))
+			}
+			uiTitles[i] = ui.Title{
+				ID:       t.ID,
+				Name:     t.Name,
+				Duration: formatDuration(t.Duration),
+				Size:     formatSize(t.Size),
+				Info:     info,
+				Selected: preselected[t.ID],
+			}
+		}
+
+		// Show title selection UI
+		selection := ui.ShowTitleSelectionMsg{Drive: disc.Device, Titles: uiTitles, Auto: auto}
+		if auto {
+			selection.Timeout = time.Duration(a.config.Selection.ConfirmSeconds) * time.Second
+		}
+		sink.Send(selection)
+
+		// Wait for user selection (or cancellation while waiting)
+		var selectedIDs []int
+		select {
+		case selectedIDs = <-drv.titleSelectionCh:
+		case <-ctx.Done():
+			return nil
+		}
+
+		if len(selectedIDs) == 0 {
+			sink.Send(ui.ErrorMsg{Drive: disc.Device, Err: fmt.Errorf("no titles selected")})
+			disk.Eject(disc.Device)
+			return nil
+		}
+
+		// Build selectedTitles from IDs
+		selectedTitles = nil
+		for _, id := range selectedIDs {
+			for _, t := range scanResult.Titles {
+				if t.ID == id {
+					selectedTitles = append(selectedTitles, t)
+					break
+				}
+			}
+		}
+	}
+
+	// Episodes are numbered in disc playback order
+	if session != nil {
+		makemkv.SortByID(selectedTitles)
+	}
+
+	// Decide how output is named: episodes of the series session, a
+	// confirmed movie match, or MakeMKV's names under the disc label
+	folderName := disc.Name
+	var episodeNames map[int]string
+	var match *metadata.Match
+	if session != nil {
+		reserved, first, err := a.series.Reserve(scanResult.DiscName, len(selectedTitles))
+		if err == nil {
+			folderName = reserved.SeasonFolder()
+			episodeNames = make(map[int]string, len(selectedTitles))
+			for i, title := range selectedTitles {
+				episodeNames[title.ID] = reserved.EpisodeName(first + i)
+			}
+			logCh <- fmt.Sprintf("Series %s: %s is episodes %d-%d of season %d",
+				reserved.Show, scanResult.DiscName, first, first+len(selectedTitles)-1, reserved.Season)
+		} else {
+			// Session ended while this disc was being scanned
+			session = nil
+		}
+	}
+	if session == nil {
+		// Look up the proper name so output is named "Movie (Year)"
+		var err error
+		match, err = a.lookupMetadata(ctx, drv, disc.Device, scanResult.DiscName, selectedTitles, sink, logCh)
+		if err != nil {
+			return nil
+		}
+		if match != nil {
+			folderName = metadata.Filename(match.String())
+		}
+	}
+
+	job := &ripjob.Job{
+		Fingerprint: scanResult.Fingerprint(),
+		VolumeID:    scanResult.VolumeID,
+		DiscName:    scanResult.DiscName,
+		DiscType:    disc.DiscType.String(),
+		Folder:      folderName,
+		Named:       match != nil || episodeNames != nil,
+		Movie:       match != nil,
+		MainTitle:   longestTitle(selectedTitles).ID,
+		StartedAt:   time.Now(),
+	}
+	for _, title := range selectedTitles {
+		fileName := episodeNames[title.ID]
+		if match != nil && title.ID == job.MainTitle {
+			fileName = folderName
+		}
+		job.Titles = append(job.Titles, ripjob.Title{
+			ID:       title.ID,
+			Name:     title.Name,
+			FileName: fileName,
+			Status:   ripjob.StatusPending,
+		})
+	}
+	return job
+}
+
+// queueRip adds a ripped title to the encode queue unless it's already
+// queued or encoded
+func (a *App) queueRip(title ripjob.Title, discType disk.DiscType, discName string) {
+	if a.queue.HasSourcePath(title.RawPath) {
+		return
+	}
+	if _, err := os.Stat(title.EncodedPath); err == nil {
+		return
+	}
+
+	a.queue.Add(&encode.QueueItem{
+		ID:         uuid.New().String(),
+		SourcePath: title.RawPath,
+		DestPath:   title.EncodedPath,
+		DiscType:   discType,
+		DiscName:   discName,
+		TitleName:  title.Name,
+		Status:     encode.StatusQueued,
+		Progress:   0,
+		CreatedAt:  time.Now(),
+	})
+}
+
+// renameNew renames a file, unless a file already exists at the new path
+func renameNew(oldPath, newPath string) error {
+	if _, err := os.Lstat(newPath); err == nil {
+		return fmt.Errorf("%s already exists", newPath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+// saveRipTitle records a title's outcome in the job and the rip history
+func (a *App) saveRipTitle(job *ripjob.Job, index int, title ripjob.Title, ripErr error, logCh chan<- string) {
+	job.Titles[index] = title
+	if err := a.ripJobs.SetTitle(job.Fingerprint, title); err != nil {
+		logCh <- fmt.Sprintf("Failed to save rip job: %v", err)
+	}
+
+	if job.HistoryID == 0 {
+		return
+	}
+	var rawSize int64
+	if info, err := os.Stat(title.RawPath); err == nil {
+		rawSize = info.Size()
+	}
+	err := a.history.Update(job.HistoryID, func(rec *history.Record) {
+		t := rec.Title(title.ID)
+		if t == nil {
+			return
+		}
+		t.Name = title.Name
+		t.Ripped = title.Status == ripjob.StatusRipped
+		t.RipError = ""
+		if ripErr != nil {
+			t.RipError = ripErr.Error()
+		}
+		t.RawPath = title.RawPath
+		t.RawSize = rawSize
+		t.EncodedPath = title.EncodedPath
+	})
 	if err != nil {
-		return false
+		logCh <- fmt.Sprintf("Failed to save rip history: %v", err)
 	}
+}
 
-	// Check if process exists by sending signal 0
-	process, err := os.FindProcess(pid)
+// addHistory records a new rip in the history
+// Returns the record ID, or 0 if it couldn't be saved
+func (a *App) addHistory(job *ripjob.Job, scanResult *makemkv.ScanResult, drv *driveRunner, logCh chan<- string) uint64 {
+	rec := &history.Record{
+		Fingerprint: job.Fingerprint,
+		DiscName:    job.DiscName,
+		DiscType:    job.DiscType,
+		VolumeID:    scanResult.VolumeID,
+		Drive:       drv.config.Label(),
+		Folder:      job.Folder,
+		Status:      history.StatusRipping,
+		StartedAt:   job.StartedAt,
+	}
+	for _, title := range job.Titles {
+		t := history.Title{ID: title.ID, Name: title.Name}
+		for _, scanned := range scanResult.Titles {
+			if scanned.ID == title.ID {
+				t.Seconds = int(scanned.Duration.Seconds())
+				t.Size = scanned.Size
+				break
+			}
+		}
+		rec.Titles = append(rec.Titles, t)
+	}
+
+	id, err := a.history.Add(rec)
 	if err != nil {
-		return false
+		logCh <- fmt.Sprintf("Failed to save rip history: %v", err)
+		return 0
 	}
+	return id
+}
 
-	// Signal 0 doesn't actually send a signal, just checks if process exists
-	err = process.Signal(syscall.Signal(0))
-	return err == nil
+// finishHistory records the outcome of a rip once its titles were processed
+// A resumed rip finishes the same record again
+func (a *App) finishHistory(job *ripjob.Job, cancelled bool, logCh chan<- string) {
+	if job.HistoryID == 0 {
+		return
+	}
+
+	status := history.StatusComplete
+	switch {
+	case job.Done():
+	case cancelled:
+		status = history.StatusCancelled
+	default:
+		status = history.StatusPartial
+	}
+
+	now := time.Now()
+	err := a.history.Update(job.HistoryID, func(rec *history.Record) {
+		rec.Status = status
+		rec.FinishedAt = &now
+	})
+	if err != nil {
+		logCh <- fmt.Sprintf("Failed to save rip history: %v", err)
+	}
 }
 
-// findNewestMKVFile finds the most recently modified MKV file in a directory
-func findNewestMKVFile(dir string) (string, error) {
-	files, err := ioutil.ReadDir(dir)
+// confirmKnownDisc looks up earlier rips of a disc and, as configured by
+// history.known_disc, asks whether to rip it again
+// Returns false if the disc was skipped or the rip cancelled while asking
+func (a *App) confirmKnownDisc(ctx context.Context, drv *driveRunner, device string, scanResult *makemkv.ScanResult, sink Sink, logCh chan<- string) bool {
+	discName := scanResult.DiscName
+	records, err := a.history.ByFingerprint(scanResult.Fingerprint())
 	if err != nil {
-		return "", err
+		logCh <- fmt.Sprintf("Failed to read rip history: %v", err)
+		return true
+	}
+	var previous []*history.Record
+	for _, rec := range records {
+		if makemkv.SameVolume(rec.VolumeID, scanResult.VolumeID) {
+			previous = append(previous, rec)
+		}
+	}
+	if len(previous) == 0 {
+		return true
 	}
+	last := previous[0]
+	logCh <- fmt.Sprintf("%s was ripped %d time(s) before, last on %s into %s",
+		discName, len(previous), last.StartedAt.Format("2006-01-02 15:04"), last.Folder)
+
+	rerip := true
+	switch a.config.History.KnownDisc {
+	case "rip":
+	case "eject":
+		rerip = false
+	default:
+		// Nobody to ask, so rip it like any other disc
+		if a.headless {
+			break
+		}
 
-	var newestFile string
-	var newestTime time.Time
+		if len(previous) > 5 {
+			previous = previous[:5]
+		}
+		rips := make([]ui.PreviousRip, len(previous))
+		for i, rec := range previous {
+			rips[i] = ui.PreviousRip{
+				StartedAt: rec.StartedAt,
+				Folder:    rec.Folder,
+				Ripped:    rec.Ripped(),
+				Titles:    len(rec.Titles),
+				Status:    string(rec.Status),
+			}
+		}
 
-	for _, file := range files {
-		if file.IsDir() {
-			continue
+		// Drop any answer left over from a cancelled prompt
+		select {
+		case <-drv.knownDiscCh:
+		default:
 		}
+		sink.Send(ui.ShowKnownDiscMsg{Drive: device, Rips: rips})
 
-		// Check if it's an MKV file
-		if !strings.HasSuffix(strings.ToLower(file.Name()), ".mkv") {
-			continue
+		select {
+		case rerip = <-drv.knownDiscCh:
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if !rerip {
+		logCh <- fmt.Sprintf("Skipping %s, it was ripped before", discName)
+		sink.Send(ui.RipCancelledMsg{Drive: device})
+		disk.Eject(device)
+	}
+	return rerip
+}
+
+// lookupMetadata searches the metadata provider for the disc and has the user confirm the match
+// Returns nil to keep the disc label, or an error if the rip was cancelled while waiting
+func (a *App) lookupMetadata(ctx context.Context, drv *driveRunner, device, discName string, titles []makemkv.Title, sink Sink, logCh chan<- string) (*metadata.Match, error) {
+	if a.metadata == nil || len(titles) == 0 {
+		return nil, nil
+	}
+
+	query := metadata.CleanLabel(discName)
+	if query == "" {
+		return nil, nil
+	}
+	runtime := longestTitle(titles).Duration
+
+	sink.Send(ui.StatusUpdateMsg{Drive: device, Status: fmt.Sprintf("Looking up %q...", query)})
+	matches, err := a.metadata.SearchMovie(ctx, query, runtime)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logCh <- fmt.Sprintf("Metadata lookup for %q failed, keeping disc label: %v", query, err)
+		return nil, nil
+	}
+	if len(matches) == 0 {
+		logCh <- fmt.Sprintf("No metadata matches for %q, keeping disc label", query)
+		return nil, nil
+	}
+	if len(matches) > 10 {
+		matches = matches[:10]
+	}
+
+	// Nobody to confirm, so trust the best match
+	if a.headless {
+		logCh <- fmt.Sprintf("Matched %q to %s", discName, matches[0])
+		return &matches[0], nil
+	}
+
+	uiMatches := make([]ui.MetadataMatch, len(matches))
+	for i, m := range matches {
+		uiMatches[i] = ui.MetadataMatch{Name: m.String(), Overview: m.Overview}
+		if m.Runtime > 0 {
+			uiMatches[i].Runtime = formatDuration(m.Runtime)
 		}
+	}
+
+	// Drop any answer left over from a cancelled prompt
+	select {
+	case <-drv.metadataCh:
+	default:
+	}
+	sink.Send(ui.ShowMetadataMatchMsg{Drive: device, Query: query, Matches: uiMatches})
+
+	var choice int
+	select {
+	case choice = <-drv.metadataCh:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if choice < 0 || choice >= len(matches) {
+		logCh <- fmt.Sprintf("Metadata match skipped, keeping disc label %q", discName)
+		return nil, nil
+	}
+	logCh <- fmt.Sprintf("Matched %q to %s", discName, matches[choice])
+	return &matches[choice], nil
+}
+
+// titleInfo summarizes a title's source and tracks, e.g. "00800.mpls 1920x1080 eng,fra"
+func titleInfo(t makemkv.Title) string {
+	var parts []string
+	if t.SourceFile != "" {
+		parts = append(parts, t.SourceFile)
+	}
+	if res := t.Resolution(); res != "" {
+		parts = append(parts, res)
+	}
+	if langs := t.AudioLanguages(); len(langs) > 0 {
+		parts = append(parts, strings.Join(langs, ","))
+	}
+	return strings.Join(parts, " ")
+}
 
-		fullPath := filepath.Join(dir, file.Name())
-		if file.ModTime().After(newestTime) {
-			newestTime = file.ModTime()
-			newestFile = fullPath
+// longestTitle returns the title with the longest duration
+func longestTitle(titles []makemkv.Title) makemkv.Title {
+	var longest makemkv.Title
+	for _, t := range titles {
+		if t.Duration > longest.Duration {
+			longest = t
 		}
 	}
+	return longest
+}
 
-	if newestFile == "" {
-		return "", fmt.Errorf("no MKV files found in %s", dir)
+// isProcessRunning checks if the process in the lock file is still running
+func isProcessRunning(lockPath string) bool {
+	data, err := ioutil.ReadFile(lockPath)
+	if err != nil {
+		return false
 	}
 
-	return newestFile, nil
+	pidStr := strings.TrimSpace(string(data))
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil {
+		return false
+	}
+
+	// Check if process exists by sending signal 0
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	// Signal 0 doesn't actually send a signal, just checks if process exists
+	err = process.Signal(syscall.Signal(0))
+	return err == nil
 }
 
 // scanForMissingEncodes scans the output directory for raw files that don't have corresponding encoded files
 func (a *App) scanForMissingEncodes(logCh chan<- string) error {
-	logCh <- "Scanning for raw files missing encoded versions..."
+	_, err := ScanForMissingEncodes(a.config.OutputDir, a.queue, func(line string) {
+		logCh <- line
+	})
+	return err
+}
+
+// ScanForMissingEncodes adds raw files under outputDir without an encoded
+// counterpart to the queue, returning how many were added
+func ScanForMissingEncodes(outputDir string, queue *encode.Queue, logf func(string)) (int, error) {
+	logf("Scanning for raw files missing encoded versions...")
 
 	// Read all directories in output directory
-	dirs, err := ioutil.ReadDir(a.config.OutputDir)
+	dirs, err := ioutil.ReadDir(outputDir)
 	if err != nil {
-		return fmt.Errorf("failed to read output directory: %w", err)
+		return 0, fmt.Errorf("failed to read output directory: %w", err)
 	}
 
 	addedCount := 0
 
+	// Disc and movie folders sit directly in the output directory,
+	// series episodes one level deeper in "Show/Season 01"
+	type folder struct {
+		path   string
+		name   string
+		season bool
+	}
+	var folders []folder
 	for _, dir := range dirs {
 		if !dir.IsDir() {
 			continue
 		}
 
-		discFolder := filepath.Join(a.config.OutputDir, dir.Name())
+		path := filepath.Join(outputDir, dir.Name())
+		folders = append(folders, folder{path: path, name: dir.Name()})
+
+		subdirs, _ := ioutil.ReadDir(path)
+		for _, sub := range subdirs {
+			if sub.IsDir() && strings.HasPrefix(sub.Name(), "Season ") {
+				folders = append(folders, folder{path: filepath.Join(path, sub.Name()), name: dir.Name(), season: true})
+			}
+		}
+	}
+
+	for _, f := range folders {
+		discFolder := f.path
 		rawFolder := filepath.Join(discFolder, "raw")
 		encodedFolder := filepath.Join(discFolder, "encoded")
 
@@ -535,13 +1473,18 @@ func (a *App) scanForMissingEncodes(logCh chan<- string) error {
 			sourcePath := filepath.Join(rawFolder, rawFile.Name())
 			destPath := filepath.Join(encodedFolder, rawFile.Name())
 
+			// Episodes and files named after a metadata match are encoded into their folder
+			if f.season || isNamedRawFile(f.name, rawFile.Name()) {
+				destPath = filepath.Join(discFolder, rawFile.Name())
+			}
+
 			// Check if encoded version already exists
 			if _, err := os.Stat(destPath); err == nil {
 				continue // Encoded file exists, skip
 			}
 
 			// Check if already in queue
-			if a.queue.HasSourcePath(sourcePath) {
+			if queue.HasSourcePath(sourcePath) {
 				continue // Already in queue, skip
 			}
 
@@ -557,26 +1500,40 @@ func (a *App) scanForMissingEncodes(logCh chan<- string) error {
 				SourcePath: sourcePath,
 				DestPath:   destPath,
 				DiscType:   discType,
-				DiscName:   dir.Name(),
+				DiscName:   f.name,
 				TitleName:  rawFile.Name(),
 				Status:     encode.StatusQueued,
 			}
 
-			if err := a.queue.Add(item); err != nil {
-				logCh <- fmt.Sprintf("Failed to add %s to queue: %v", rawFile.Name(), err)
+			if err := queue.Add(item); err != nil {
+				logf(fmt.Sprintf("Failed to add %s to queue: %v", rawFile.Name(), err))
 				continue
 			}
 
-			logCh <- fmt.Sprintf("Added to queue: %s", rawFile.Name())
+			logf(fmt.Sprintf("Added to queue: %s", rawFile.Name()))
 			addedCount++
 		}
 	}
 
 	if addedCount == 0 {
-		logCh <- "No missing encodes found"
+		logf("No missing encodes found")
 	} else {
-		logCh <- fmt.Sprintf("Added %d item(s) to encoding queue", addedCount)
+		logf(fmt.Sprintf("Added %d item(s) to encoding queue", addedCount))
 	}
 
-	return nil
+	return addedCount, nil
+}
+
+// makemkvNameRegex matches the names MakeMKV gives ripped titles, e.g.
+// "title_t00.mkv", with the suffix added when the name was taken ("title_t00-2.mkv")
+var makemkvNameRegex = regexp.MustCompile(`_t\d{2}(-\d+)?\.mkv$`)
+
+// isNamedRawFile reports whether a raw file was renamed after a metadata match
+// ("Movie (Year).mkv" or "Movie (Year) - title_t01-other.mkv" in folder "Movie (Year)")
+// Files still under their MakeMKV name were never renamed, whatever the folder is called
+func isNamedRawFile(folderName, fileName string) bool {
+	if makemkvNameRegex.MatchString(fileName) {
+		return false
+	}
+	return fileName == folderName+".mkv" || strings.HasPrefix(fileName, folderName+" - ")
 }
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/mmzim/mkvauto/internal/api"
+	"github.com/mmzim/mkvauto/internal/encode"
+	"github.com/mmzim/mkvauto/internal/ui"
+)
+
+// App implements api.Controller
+var _ api.Controller = (*App)(nil)
+
+// Drives returns the current rip state of every drive
+func (a *App) Drives() []api.DriveStatus {
+	return a.tracker.Drives()
+}
+
+// ControlEncode forwards a control command to the worker encoding an item,
+// or to every worker for an empty item ID
+func (a *App) ControlEncode(itemID string, ctrl encode.WorkerControl) error {
+	select {
+	case a.workerControl <- encode.ItemControl{ItemID: itemID, Control: ctrl}:
+	default:
+		return api.ErrBusy
+	}
+
+	// Keep the TUI's paused markers in step with the workers
+	switch ctrl {
+	case encode.WorkerPause:
+		a.sink.Send(ui.EncodePausedMsg{ItemID: itemID, Paused: true})
+	case encode.WorkerResume, encode.WorkerStop, encode.WorkerDelete:
+		a.sink.Send(ui.EncodePausedMsg{ItemID: itemID})
+	}
+	return nil
+}
+
+// Hold tells whether new encodes are held back
+func (a *App) Hold() api.EncodeHold {
+	return a.tracker.Hold()
+}
+
+// CancelRip cancels the disc on a drive, identified by device path, name or
+// device base name (e.g. "sr0"), and ejects it
+func (a *App) CancelRip(drive string) error {
+	drv := a.findDrive(drive)
+	if drv == nil {
+		return fmt.Errorf("%w: %s", api.ErrUnknownDrive, drive)
+	}
+
+	select {
+	case drv.cancelRipCh <- struct{}{}:
+	default:
+		// A cancel is already pending
+	}
+	return nil
+}
+
+// PauseRip suspends or resumes the rip running on a drive
+func (a *App) PauseRip(drive string, pause bool) error {
+	drv := a.findDrive(drive)
+	if drv == nil {
+		return fmt.Errorf("%w: %s", api.ErrUnknownDrive, drive)
+	}
+	if !a.tracker.ripping(drv.config.Path) {
+		return fmt.Errorf("%w: %s", api.ErrNotRipping, drv.config.Path)
+	}
+
+	select {
+	case drv.pauseRipCh <- pause:
+		return nil
+	default:
+		return fmt.Errorf("%w: a pause request is already pending on %s", api.ErrBusy, drv.config.Path)
+	}
+}
+
+// MoveTray opens or closes the tray of a drive
+// Opening is refused while the drive is processing a disc; cancel the rip instead
+func (a *App) MoveTray(drive string, open bool) error {
+	drv := a.findDrive(drive)
+	if drv == nil {
+		return fmt.Errorf("%w: %s", api.ErrUnknownDrive, drive)
+	}
+	if open && a.tracker.busy(drv.config.Path) {
+		return fmt.Errorf("%w: %s is processing a disc, cancel the rip to eject it", api.ErrBusy, drv.config.Path)
+	}
+
+	select {
+	case drv.trayCh <- open:
+		return nil
+	default:
+		return fmt.Errorf("%w: the tray of %s is already moving", api.ErrBusy, drv.config.Path)
+	}
+}
+
+// StartRip processes the disc already sitting in a drive
+// Useful when the disc was inserted before mkvauto started
+func (a *App) StartRip(drive string) error {
+	drv := a.findDrive(drive)
+	if drv == nil {
+		return fmt.Errorf("%w: %s", api.ErrUnknownDrive, drive)
+	}
+	if a.tracker.busy(drv.config.Path) {
+		return fmt.Errorf("%w: %s is already processing a disc", api.ErrBusy, drv.config.Path)
+	}
+	if !drv.detector.IsDiscPresent() {
+		return fmt.Errorf("%w: %s", api.ErrNoDisc, drv.config.Path)
+	}
+
+	select {
+	case drv.ripRequestCh <- struct{}{}:
+		return nil
+	default:
+		return fmt.Errorf("%w: a rip is already pending on %s", api.ErrBusy, drv.config.Path)
+	}
+}
+
+// ScanMissing requests a scan for raw files missing encoded versions
+func (a *App) ScanMissing() error {
+	select {
+	case a.scanRequestCh <- struct{}{}:
+	default:
+		// A scan is already pending
+	}
+	return nil
+}
+
+// findDrive looks up a drive by device path, configured name or device base name
+func (a *App) findDrive(name string) *driveRunner {
+	for _, drv := range a.drives {
+		if drv.config.Path == name || drv.config.Name == name || filepath.Base(drv.config.Path) == name {
+			return drv
+		}
+	}
+	return nil
+}
+
+// serveAPI runs the control API on the local socket, and on TCP if a listen
+// address is configured
+func (a *App) serveAPI(ctx context.Context, logCh chan<- string) {
+	server := api.NewServer(a.queue, a.series, a, a.events)
+
+	go func() {
+		// We hold the instance lock, so any existing socket is stale
+		socketPath := SocketPath()
+		os.Remove(socketPath)
+		defer os.Remove(socketPath)
+
+		ln, err := net.Listen("unix", socketPath)
+		if err != nil {
+			logCh <- fmt.Sprintf("Control socket unavailable: %v", err)
+			return
+		}
+		os.Chmod(socketPath, 0600)
+
+		if err := server.Serve(ctx, ln); err != nil {
+			logCh <- fmt.Sprintf("Control socket stopped: %v", err)
+		}
+	}()
+
+	if a.config.API.Listen == "" {
+		return
+	}
+
+	logCh <- fmt.Sprintf("Control API listening on %s", a.config.API.Listen)
+	if err := server.ListenAndServe(ctx, a.config.API.Listen); err != nil {
+		logCh <- fmt.Sprintf("Control API stopped: %v", err)
+	}
+}
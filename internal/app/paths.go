@@ -0,0 +1,52 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// StateDir returns the directory holding queue state, logs and the instance lock
+func StateDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".mkvauto")
+}
+
+// QueuePath returns the path of the persisted encode queue
+func QueuePath() string {
+	return filepath.Join(StateDir(), "queue.json")
+}
+
+// SeriesPath returns the path of the persisted TV series session
+func SeriesPath() string {
+	return filepath.Join(StateDir(), "series.json")
+}
+
+// RipJobsPath returns the path of unfinished rip jobs
+func RipJobsPath() string {
+	return filepath.Join(StateDir(), "ripjobs.json")
+}
+
+// HistoryPath returns the path of the rip history database
+func HistoryPath() string {
+	return filepath.Join(StateDir(), "history.db")
+}
+
+// NotifySpoolPath returns the path of undelivered notifications
+func NotifySpoolPath() string {
+	return filepath.Join(StateDir(), "notify-spool.json")
+}
+
+// LockPath returns the path of the single-instance lock file
+func LockPath() string {
+	return filepath.Join(StateDir(), "mkvauto.lock")
+}
+
+// SocketPath returns the path of the control socket of a running instance
+func SocketPath() string {
+	return filepath.Join(StateDir(), "mkvauto.sock")
+}
+
+// InstanceRunning reports whether another mkvauto process holds the instance lock
+func InstanceRunning() bool {
+	return isProcessRunning(LockPath())
+}
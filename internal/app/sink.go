@@ -0,0 +1,210 @@
+package app
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmzim/mkvauto/internal/ui"
+)
+
+// Sink receives pipeline events (the ui message types)
+// *tea.Program satisfies it, so the TUI is just one implementation
+type Sink interface {
+	Send(msg tea.Msg)
+}
+
+// logSink writes pipeline events as structured log records
+type logSink struct {
+	logger *slog.Logger
+	drives []*driveRunner // Prompts are answered on their channels
+
+	// Last logged progress step per drive/item, to avoid flooding the log
+	mu            sync.Mutex
+	ripSteps      map[string]int
+	encodeSteps   map[string]int
+	progressEvery float64
+}
+
+// newLogSink creates a sink for headless operation
+// Progress is logged every 10%, raw tool output only at debug level.
+// Nobody can answer prompts, so they get the answers the pipeline itself
+// uses without a UI: the automatic selection, rip again, the best match.
+func newLogSink(logger *slog.Logger, drives []*driveRunner) Sink {
+	return &logSink{
+		logger:        logger,
+		drives:        drives,
+		ripSteps:      make(map[string]int),
+		encodeSteps:   make(map[string]int),
+		progressEvery: 10,
+	}
+}
+
+func (s *logSink) Send(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case ui.DiskInsertedMsg:
+		s.resetStep(s.ripSteps, msg.Drive)
+		s.logger.Info("disc inserted", "drive", msg.Drive)
+
+	case ui.MediaChangedMsg:
+		if msg.Err != nil {
+			s.logger.Warn("drive unavailable", "drive", msg.Drive, "media", msg.State.String(), "error", msg.Err)
+		} else {
+			s.logger.Info("drive media changed", "drive", msg.Drive, "media", msg.State.String())
+		}
+
+	case ui.StatusUpdateMsg:
+		s.logger.Debug("status", "drive", msg.Drive, "status", msg.Status)
+
+	case ui.ScanCompleteMsg:
+		s.logger.Info("scan complete", "drive", msg.Drive, "disc", msg.Info.Name, "type", msg.Info.DiscType)
+
+	case ui.RipProgressMsg:
+		if s.shouldLogStep(s.ripSteps, msg.Drive, msg.Progress) {
+			s.logger.Info("ripping", "drive", msg.Drive, "title", msg.CurrentTitle, "titles", msg.TotalTitles, "progress", roundProgress(msg.Progress))
+		}
+
+	case ui.RipCompleteMsg:
+		s.resetStep(s.ripSteps, msg.Drive)
+		s.logger.Info("rip complete", "drive", msg.Drive)
+
+	case ui.RipCancelledMsg:
+		s.resetStep(s.ripSteps, msg.Drive)
+		s.logger.Info("rip cancelled", "drive", msg.Drive)
+
+	case ui.RipPausedMsg:
+		if msg.Paused {
+			s.logger.Info("rip paused", "drive", msg.Drive)
+		} else {
+			s.logger.Info("rip resumed", "drive", msg.Drive)
+		}
+
+	case ui.ShowTitleSelectionMsg:
+		var ids []int
+		for _, t := range msg.Titles {
+			if t.Selected {
+				ids = append(ids, t.ID)
+			}
+		}
+		s.logger.Warn("title selection prompt without a UI, using the automatic selection", "drive", msg.Drive, "titles", ids)
+		if drv := s.drive(msg.Drive); drv != nil {
+			select {
+			case drv.titleSelectionCh <- ids:
+			default: // An answer is already waiting
+			}
+		}
+
+	case ui.ShowKnownDiscMsg:
+		s.logger.Warn("known disc prompt without a UI, ripping it again", "drive", msg.Drive, "previous_rips", len(msg.Rips))
+		if drv := s.drive(msg.Drive); drv != nil {
+			select {
+			case drv.knownDiscCh <- true:
+			default: // An answer is already waiting
+			}
+		}
+
+	case ui.ShowMetadataMatchMsg:
+		if len(msg.Matches) > 0 {
+			s.logger.Warn("metadata prompt without a UI, using the best match", "drive", msg.Drive, "query", msg.Query, "match", msg.Matches[0].Name)
+		}
+		if drv := s.drive(msg.Drive); drv != nil {
+			select {
+			case drv.metadataCh <- 0:
+			default: // An answer is already waiting
+			}
+		}
+
+	case ui.EncodeProgressMsg:
+		if s.shouldLogStep(s.encodeSteps, msg.ItemID, msg.Progress) {
+			s.logger.Info("encoding", "item", msg.ItemID, "progress", roundProgress(msg.Progress))
+		}
+
+	case ui.EncodePausedMsg:
+		item := msg.ItemID
+		if item == "" {
+			item = "all"
+		}
+		if msg.Paused {
+			s.logger.Info("encode paused", "item", item)
+		} else {
+			s.logger.Info("encode resumed", "item", item)
+		}
+
+	case ui.EncodeCompleteMsg:
+		s.resetStep(s.encodeSteps, msg.ItemID)
+		s.logger.Info("encode complete", "item", msg.ItemID)
+
+	case ui.EncodeHeldMsg:
+		if msg.Reason != "" {
+			s.logger.Info("encodes held", "reason", msg.Reason, "next", msg.Next)
+		} else {
+			s.logger.Info("encodes running", "next", msg.Next)
+		}
+		if msg.Throttle != "" {
+			s.logger.Info("encoder priority lowered", "reason", msg.Throttle)
+		}
+
+	case ui.ErrorMsg:
+		s.logger.Error("pipeline error", "drive", msg.Drive, "error", msg.Err)
+
+	case ui.LogMsg:
+		switch {
+		case msg.Output:
+			s.logger.Debug(msg.Line)
+		case isProblem(msg.Line):
+			s.logger.Warn(msg.Line)
+		default:
+			s.logger.Info(msg.Line)
+		}
+	}
+}
+
+// problemWords mark log lines about something that went wrong
+var problemWords = []string{"fail", "cannot", "could not", "not found", "unavailable", "stopped", "dropped", "spool:"}
+
+// isProblem reports whether a log line of mkvauto's reports a problem
+func isProblem(line string) bool {
+	line = strings.ToLower(line)
+	for _, w := range problemWords {
+		if strings.Contains(line, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// drive returns the runner of a drive, nil if it isn't configured
+func (s *logSink) drive(path string) *driveRunner {
+	for _, drv := range s.drives {
+		if drv.config.Path == path {
+			return drv
+		}
+	}
+	return nil
+}
+
+// shouldLogStep reports whether progress has crossed into a new step for key
+func (s *logSink) shouldLogStep(steps map[string]int, key string, progress float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	step := int(progress / s.progressEvery)
+	last, seen := steps[key]
+	if seen && step <= last {
+		return false
+	}
+	steps[key] = step
+	return true
+}
+
+func (s *logSink) resetStep(steps map[string]int, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(steps, key)
+}
+
+func roundProgress(p float64) float64 {
+	return float64(int(p*10)) / 10
+}
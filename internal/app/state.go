@@ -0,0 +1,269 @@
+package app
+
+import (
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmzim/mkvauto/internal/api"
+	"github.com/mmzim/mkvauto/internal/ui"
+)
+
+// multiSink fans events out to several sinks
+type multiSink []Sink
+
+func (m multiSink) Send(msg tea.Msg) {
+	for _, s := range m {
+		s.Send(msg)
+	}
+}
+
+// stateTracker records the latest rip state per drive from pipeline events
+// so it can be queried outside the TUI
+type stateTracker struct {
+	mu     sync.RWMutex
+	drives []*api.DriveStatus
+	hold   api.EncodeHold
+}
+
+func newStateTracker(drives []*driveRunner) *stateTracker {
+	t := &stateTracker{}
+	for _, drv := range drives {
+		t.drives = append(t.drives, &api.DriveStatus{
+			Device: drv.config.Path,
+			Name:   drv.config.Label(),
+			State:  ui.StateWaiting.String(),
+		})
+	}
+	return t
+}
+
+func (t *stateTracker) Send(msg tea.Msg) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch msg := msg.(type) {
+	case ui.EncodeHeldMsg:
+		t.hold = api.EncodeHold{Reason: msg.Reason, Next: msg.Next, Throttle: msg.Throttle}
+
+	case ui.MediaChangedMsg:
+		if d := t.drive(msg.Drive); d != nil {
+			d.Media = msg.State.String()
+			d.MediaError = ""
+			if msg.Err != nil {
+				d.MediaError = msg.Err.Error()
+			}
+		}
+
+	case ui.DiskInsertedMsg:
+		if d := t.drive(msg.Drive); d != nil {
+			*d = api.DriveStatus{Device: d.Device, Name: d.Name, Media: d.Media, MediaError: d.MediaError, State: ui.StateScanning.String()}
+		}
+
+	case ui.StatusUpdateMsg:
+		if d := t.drive(msg.Drive); d != nil {
+			d.Status = msg.Status
+		}
+
+	case ui.ScanCompleteMsg:
+		if d := t.drive(msg.Drive); d != nil {
+			d.State = ui.StateRipping.String()
+			d.Disc = msg.Info.Name
+			d.DiscType = msg.Info.DiscType
+			d.Status = ""
+		}
+
+	case ui.ShowTitleSelectionMsg:
+		if d := t.drive(msg.Drive); d != nil {
+			d.State = ui.StateSelectingTitles.String()
+		}
+
+	case ui.ShowMetadataMatchMsg:
+		if d := t.drive(msg.Drive); d != nil {
+			d.State = ui.StateConfirmingMetadata.String()
+		}
+
+	case ui.ShowKnownDiscMsg:
+		if d := t.drive(msg.Drive); d != nil {
+			d.State = ui.StateConfirmingRerip.String()
+		}
+
+	case ui.RipProgressMsg:
+		if d := t.drive(msg.Drive); d != nil {
+			d.State = ui.StateRipping.String()
+			d.Progress = msg.Progress
+			d.CurrentTitle = msg.CurrentTitle
+			d.TotalTitles = msg.TotalTitles
+		}
+
+	case ui.RipCompleteMsg:
+		if d := t.drive(msg.Drive); d != nil {
+			d.State = ui.StateComplete.String()
+			d.Progress = 100
+			d.Status = ""
+			d.Paused = false
+		}
+
+	case ui.RipCancelledMsg:
+		if d := t.drive(msg.Drive); d != nil {
+			*d = api.DriveStatus{Device: d.Device, Name: d.Name, Media: d.Media, MediaError: d.MediaError, State: ui.StateWaiting.String()}
+		}
+
+	case ui.RipPausedMsg:
+		if d := t.drive(msg.Drive); d != nil {
+			d.Paused = msg.Paused
+		}
+
+	case ui.ErrorMsg:
+		if d := t.drive(msg.Drive); d != nil {
+			d.LastError = msg.Err.Error()
+			// A failed title doesn't stop the rip of the others, so the
+			// drive stays busy until RipCompleteMsg or RipCancelledMsg
+			if d.State != ui.StateRipping.String() || d.TotalTitles == 0 {
+				d.State = ui.StateError.String()
+			}
+		}
+	}
+}
+
+// Drives returns a copy of the current drive states
+func (t *stateTracker) Drives() []api.DriveStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	out := make([]api.DriveStatus, len(t.drives))
+	for i, d := range t.drives {
+		out[i] = *d
+	}
+	return out
+}
+
+// Hold returns whether new encodes are held back
+func (t *stateTracker) Hold() api.EncodeHold {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.hold
+}
+
+// busy reports whether a drive is currently processing a disc
+func (t *stateTracker) busy(device string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	d := t.drive(device)
+	if d == nil {
+		return false
+	}
+	switch d.State {
+	case ui.StateScanning.String(), ui.StateSelectingTitles.String(), ui.StateConfirmingMetadata.String(),
+		ui.StateConfirmingRerip.String(), ui.StateRipping.String():
+		return true
+	}
+	return false
+}
+
+// ripping reports whether a drive is past title selection and ripping
+func (t *stateTracker) ripping(device string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	d := t.drive(device)
+	return d != nil && d.State == ui.StateRipping.String()
+}
+
+// drive returns the status for a device path; caller must hold the lock
+func (t *stateTracker) drive(device string) *api.DriveStatus {
+	for _, d := range t.drives {
+		if d.Device == device {
+			return d
+		}
+	}
+	return nil
+}
+
+// eventSink publishes pipeline events to API stream subscribers
+type eventSink struct {
+	broker *api.Broker
+}
+
+func (s *eventSink) Send(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case ui.MediaChangedMsg:
+		event := api.MediaChangedEvent{Drive: msg.Drive, Media: msg.State.String()}
+		if msg.Err != nil {
+			event.Error = msg.Err.Error()
+		}
+		s.broker.Publish(api.EventMediaChanged, event)
+
+	case ui.DiskInsertedMsg:
+		s.broker.Publish(api.EventDiscInserted, api.DriveEvent{Drive: msg.Drive})
+
+	case ui.StatusUpdateMsg:
+		s.broker.Publish(api.EventStatus, api.StatusEvent{Drive: msg.Drive, Status: msg.Status})
+
+	case ui.ScanCompleteMsg:
+		s.broker.Publish(api.EventScanComplete, api.ScanCompleteEvent{
+			Drive:    msg.Drive,
+			Disc:     msg.Info.Name,
+			DiscType: msg.Info.DiscType,
+		})
+
+	case ui.ShowTitleSelectionMsg:
+		titles := make([]api.Title, len(msg.Titles))
+		for i, t := range msg.Titles {
+			titles[i] = api.Title(t)
+		}
+		s.broker.Publish(api.EventTitleSelection, api.TitleSelectionEvent{
+			Drive:   msg.Drive,
+			Titles:  titles,
+			Auto:    msg.Auto,
+			Timeout: int(msg.Timeout.Seconds()),
+		})
+
+	case ui.ShowKnownDiscMsg:
+		rips := make([]api.PreviousRip, len(msg.Rips))
+		for i, r := range msg.Rips {
+			rips[i] = api.PreviousRip(r)
+		}
+		s.broker.Publish(api.EventKnownDisc, api.KnownDiscEvent{Drive: msg.Drive, Rips: rips})
+
+	case ui.ShowMetadataMatchMsg:
+		matches := make([]api.MetadataMatch, len(msg.Matches))
+		for i, m := range msg.Matches {
+			matches[i] = api.MetadataMatch(m)
+		}
+		s.broker.Publish(api.EventMetadataMatch, api.MetadataMatchEvent{Drive: msg.Drive, Query: msg.Query, Matches: matches})
+
+	case ui.RipProgressMsg:
+		s.broker.Publish(api.EventRipProgress, api.RipProgressEvent{
+			Drive:        msg.Drive,
+			Progress:     msg.Progress,
+			CurrentTitle: msg.CurrentTitle,
+			TotalTitles:  msg.TotalTitles,
+		})
+
+	case ui.RipCompleteMsg:
+		s.broker.Publish(api.EventRipComplete, api.DriveEvent{Drive: msg.Drive})
+
+	case ui.RipCancelledMsg:
+		s.broker.Publish(api.EventRipCancelled, api.DriveEvent{Drive: msg.Drive})
+
+	case ui.RipPausedMsg:
+		s.broker.Publish(api.EventRipPaused, api.RipPausedEvent{Drive: msg.Drive, Paused: msg.Paused})
+
+	case ui.EncodeProgressMsg:
+		s.broker.Publish(api.EventEncodeProgress, api.EncodeProgressEvent{ItemID: msg.ItemID, Progress: msg.Progress})
+
+	case ui.EncodeCompleteMsg:
+		s.broker.Publish(api.EventEncodeComplete, api.EncodeProgressEvent{ItemID: msg.ItemID, Progress: 100})
+
+	case ui.EncodeHeldMsg:
+		s.broker.Publish(api.EventEncodeHeld, api.EncodeHold{Reason: msg.Reason, Next: msg.Next, Throttle: msg.Throttle})
+
+	case ui.ErrorMsg:
+		s.broker.Publish(api.EventError, api.ErrorEvent{Drive: msg.Drive, Error: msg.Err.Error()})
+
+	case ui.LogMsg:
+		s.broker.Publish(api.EventLog, api.LogEvent{Line: msg.Line, Output: msg.Output})
+	}
+}
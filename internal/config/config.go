@@ -5,21 +5,118 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	OutputDir       string       `mapstructure:"output_dir"`
-	DiscordWebhook  string       `mapstructure:"discord_webhook"`
+	DiscordWebhook  string       `mapstructure:"discord_webhook"` // Shorthand for a single discord notifier
+	Notifiers       []NotifierConfig `mapstructure:"notifiers"`
 	Drive           DriveConfig  `mapstructure:"drive"`
+	Drives          []DriveConfig `mapstructure:"drives"` // Multiple drives (overrides drive if set)
 	Thresholds      Thresholds   `mapstructure:"thresholds"`
 	MakeMKV         MakeMKVConfig `mapstructure:"makemkv"`
 	HandBrake       HandBrakeConfig `mapstructure:"handbrake"`
+	API             APIConfig       `mapstructure:"api"`
+	Metadata        MetadataConfig  `mapstructure:"metadata"`
+	Selection       SelectionConfig `mapstructure:"selection"`
+	History         HistoryConfig   `mapstructure:"history"`
+	Queue           QueueConfig     `mapstructure:"queue"`
+	Schedule        ScheduleConfig  `mapstructure:"schedule"`
+	Throttle        ThrottleConfig  `mapstructure:"throttle"`
+	Detection       string          `mapstructure:"detection"` // Disc detection: auto, uevent or poll
+}
+
+type ThrottleConfig struct {
+	MaxLoad        float64 `mapstructure:"max_load"`         // 1-minute load average (0 = ignore)
+	MaxCPUPressure float64 `mapstructure:"max_cpu_pressure"` // % of time tasks waited for a CPU, from /proc/pressure/cpu (0 = ignore)
+	MaxTemperature float64 `mapstructure:"max_temperature"`  // °C of the hottest thermal zone (0 = ignore)
+	Action         string  `mapstructure:"action"`           // pause (default) or nice
+	Interval       int     `mapstructure:"interval"`         // Seconds between samples (default 10)
+	MinDwell       int     `mapstructure:"min_dwell"`        // Seconds encodes stay paused or running before the next change (default 300)
+}
+
+type ScheduleConfig struct {
+	Windows      []ScheduleWindow `mapstructure:"windows"`       // Encodes only start inside a window (none = any time)
+	PauseRunning bool             `mapstructure:"pause_running"` // Suspend running encodes when a window closes
+}
+
+// ScheduleWindow is a time range on some days in which encodes may run
+type ScheduleWindow struct {
+	Days  []string `mapstructure:"days"`  // mon..sun, weekdays or weekends (empty = every day)
+	Start string   `mapstructure:"start"` // HH:MM (empty = midnight)
+	End   string   `mapstructure:"end"`   // HH:MM, at or before start crosses midnight (empty = midnight)
+}
+
+type QueueConfig struct {
+	Policy string `mapstructure:"policy"` // Where new items go: fifo, shortest_first or dvd_first
+}
+
+type HistoryConfig struct {
+	KnownDisc string `mapstructure:"known_disc"` // Disc ripped before: ask, rip or eject
+}
+
+type SelectionConfig struct {
+	Rules          []SelectionRule `mapstructure:"rules"`           // Checked in order, first match wins
+	Confirm        bool            `mapstructure:"confirm"`         // Show the picker even when titles were selected automatically
+	ConfirmSeconds int             `mapstructure:"confirm_seconds"` // Countdown before the automatic selection proceeds (0 = wait)
+}
+
+// SelectionRule picks titles for discs matching all of its conditions
+// Empty conditions match anything
+type SelectionRule struct {
+	Name           string   `mapstructure:"name"`
+	Label          string   `mapstructure:"label"`           // Regex matched against the disc label
+	DiscType       string   `mapstructure:"disc_type"`       // dvd or bluray
+	MinTitles      int      `mapstructure:"min_titles"`      // Number of titles passing the filters below
+	MaxTitles      int      `mapstructure:"max_titles"`
+	MinMinutes     int      `mapstructure:"min_minutes"`     // Title duration range
+	MaxMinutes     int      `mapstructure:"max_minutes"`
+	MinChapters    int      `mapstructure:"min_chapters"`
+	AudioLanguages []string `mapstructure:"audio_languages"` // Title has any of these audio tracks
+	Action         string   `mapstructure:"action"`          // longest, episodes, all or ask
+}
+
+type MetadataConfig struct {
+	Provider string `mapstructure:"provider"` // tmdb or empty to keep disc labels
+	APIKey   string `mapstructure:"api_key"`  // TMDB v3 API key or v4 read access token
+	Language string `mapstructure:"language"` // e.g. "en-US" (optional)
+}
+
+type APIConfig struct {
+	Listen string `mapstructure:"listen"` // HTTP listen address, e.g. "127.0.0.1:8420" (empty = disabled)
+}
+
+type NotifierConfig struct {
+	Type     string            `mapstructure:"type"`     // discord, webhook, ntfy, gotify, email
+	URL      string            `mapstructure:"url"`      // Webhook URL or server base URL
+	Topic    string            `mapstructure:"topic"`    // ntfy topic
+	Token    string            `mapstructure:"token"`    // ntfy access token or gotify app token
+	Headers  map[string]string `mapstructure:"headers"`  // Extra HTTP headers (webhook)
+	SMTPHost string            `mapstructure:"smtp_host"`
+	SMTPPort int               `mapstructure:"smtp_port"` // Defaults to 587
+	Username string            `mapstructure:"username"`
+	Password string            `mapstructure:"password"`
+	From     string            `mapstructure:"from"`
+	To       []string          `mapstructure:"to"`
+	Events   []string          `mapstructure:"events"` // Event kinds to send (empty = all)
 }
 
 type DriveConfig struct {
-	Path string `mapstructure:"path"`
+	Path      string `mapstructure:"path"`
+	Name      string `mapstructure:"name"`       // Display name (defaults to path)
+	DiscIndex *int   `mapstructure:"disc_index"` // MakeMKV disc index (auto-detected if omitted)
+}
+
+// Label returns the display name for the drive
+func (d DriveConfig) Label() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return d.Path
 }
 
 type Thresholds struct {
@@ -34,7 +131,10 @@ type MakeMKVConfig struct {
 type HandBrakeConfig struct {
 	BinaryPath  string              `mapstructure:"binary_path"`
 	PresetsDir  string              `mapstructure:"presets_dir"`
-	Threads     int                 `mapstructure:"threads"` // Number of threads (0 = auto)
+	Threads     int                 `mapstructure:"threads"` // Number of threads per worker (0 = auto)
+	Workers     int                 `mapstructure:"workers"` // Number of encodes running at once
+	SegmentMinutes int              `mapstructure:"segment_minutes"` // Encode in segments of about this length so interrupted encodes resume (0 = off)
+	MkvmergePath   string           `mapstructure:"mkvmerge_path"`   // Joins the encoded segments
 	BluRay      HandBrakeProfile    `mapstructure:"bluray"`
 	DVD         HandBrakeProfile    `mapstructure:"dvd"`
 }
@@ -65,10 +165,15 @@ func Load(configPath string) (*Config, error) {
 
 	// Set defaults
 	v.SetDefault("drive.path", "/dev/sr0")
+	v.SetDefault("detection", "auto")
 	v.SetDefault("thresholds.movie_min_minutes", 60)
 	v.SetDefault("thresholds.episode_min_minutes", 18)
+	v.SetDefault("selection.confirm_seconds", 30)
+	v.SetDefault("history.known_disc", "ask")
 	v.SetDefault("makemkv.binary_path", "makemkvcon")
 	v.SetDefault("handbrake.binary_path", "HandBrakeCLI")
+	v.SetDefault("handbrake.workers", 1)
+	v.SetDefault("handbrake.mkvmerge_path", "mkvmerge")
 
 	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
@@ -79,6 +184,11 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	// Single drive config is shorthand for a one-element drives list
+	if len(cfg.Drives) == 0 {
+		cfg.Drives = []DriveConfig{cfg.Drive}
+	}
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		return nil, err
@@ -97,13 +207,64 @@ func (c *Config) Validate() error {
 	if c.OutputDir == "" {
 		return fmt.Errorf("output_dir is required")
 	}
-	if c.DiscordWebhook == "" {
-		return fmt.Errorf("discord_webhook is required")
+	if len(c.Drives) == 0 {
+		return fmt.Errorf("at least one drive is required")
+	}
+	seen := make(map[string]bool)
+	for i, d := range c.Drives {
+		if d.Path == "" {
+			return fmt.Errorf("drives[%d].path is required", i)
+		}
+		if seen[d.Path] {
+			return fmt.Errorf("drive %s is configured more than once", d.Path)
+		}
+		seen[d.Path] = true
+	}
+
+	switch c.Metadata.Provider {
+	case "":
+	case "tmdb":
+		if c.Metadata.APIKey == "" {
+			return fmt.Errorf("metadata.api_key is required for tmdb")
+		}
+	default:
+		return fmt.Errorf("unknown metadata provider %q", c.Metadata.Provider)
+	}
+
+	if c.Selection.ConfirmSeconds < 0 {
+		return fmt.Errorf("selection.confirm_seconds must not be negative")
+	}
+	for i, r := range c.Selection.Rules {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("selection.rules[%d]: %w", i, err)
+		}
+	}
+
+	if c.HandBrake.Workers < 1 {
+		return fmt.Errorf("handbrake.workers must be at least 1")
+	}
+	if c.HandBrake.Threads < 0 {
+		return fmt.Errorf("handbrake.threads must not be negative")
 	}
-	if c.Drive.Path == "" {
-		return fmt.Errorf("drive.path is required")
+	if c.HandBrake.SegmentMinutes < 0 {
+		return fmt.Errorf("handbrake.segment_minutes must not be negative")
 	}
 
+	switch c.Queue.Policy {
+	case "", "fifo", "shortest_first", "dvd_first":
+	default:
+		return fmt.Errorf("invalid queue.policy %q (use fifo, shortest_first or dvd_first)", c.Queue.Policy)
+	}
+
+	switch c.History.KnownDisc {
+	case "", "ask", "rip", "eject":
+	default:
+		return fmt.Errorf("invalid history.known_disc %q (use ask, rip or eject)", c.History.KnownDisc)
+	}
+
+	// Detection, schedule, throttle and notifiers are checked by the
+	// packages that build them, when the app is created
+
 	// Check if MakeMKV binary exists
 	if _, err := exec.LookPath(c.MakeMKV.BinaryPath); err != nil {
 		return fmt.Errorf("makemkv binary not found: %s", c.MakeMKV.BinaryPath)
@@ -114,5 +275,38 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("handbrake binary not found: %s", c.HandBrake.BinaryPath)
 	}
 
+	// Segmented encodes are joined with mkvmerge
+	if c.HandBrake.SegmentMinutes > 0 {
+		if _, err := exec.LookPath(c.HandBrake.MkvmergePath); err != nil {
+			return fmt.Errorf("mkvmerge binary not found: %s (needed for handbrake.segment_minutes)", c.HandBrake.MkvmergePath)
+		}
+	}
+
+	return nil
+}
+
+// validate checks the rule's pattern, disc type and action
+func (r SelectionRule) validate() error {
+	if _, err := regexp.Compile(r.Label); err != nil {
+		return fmt.Errorf("invalid label pattern: %w", err)
+	}
+	switch strings.ToLower(r.DiscType) {
+	case "", "dvd", "bluray", "blu-ray":
+	default:
+		return fmt.Errorf("invalid disc_type %q (use dvd or bluray)", r.DiscType)
+	}
+	if r.MaxMinutes > 0 && r.MaxMinutes < r.MinMinutes {
+		return fmt.Errorf("max_minutes is less than min_minutes")
+	}
+	if r.MaxTitles > 0 && r.MaxTitles < r.MinTitles {
+		return fmt.Errorf("max_titles is less than min_titles")
+	}
+	switch strings.ToLower(r.Action) {
+	case "longest", "episodes", "all", "ask":
+	case "":
+		return fmt.Errorf("action is required")
+	default:
+		return fmt.Errorf("invalid action %q (use longest, episodes, all or ask)", r.Action)
+	}
 	return nil
 }
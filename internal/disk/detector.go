@@ -16,69 +16,100 @@ const (
 	CDS_DISC_OK        = 4
 )
 
+// Event is a change of a drive's media state
+// StateDisc is only reported once the disc settled, and means a disc was inserted
+type Event struct {
+	Device string
+	State  State
+	Err    error // Why the drive can't be read, for StateError and StateMissing
+}
+
+// same reports whether two events describe the same drive state
+func (e Event) same(o Event) bool {
+	if e.State != o.State || (e.Err == nil) != (o.Err == nil) {
+		return false
+	}
+	return e.Err == nil || e.Err.Error() == o.Err.Error()
+}
+
 type Detector struct {
 	devicePath string
-	pollInterval time.Duration
+	source     Source
+	settle     time.Duration // How long a disc must stay ready before it's reported
 }
 
-func NewDetector(devicePath string) *Detector {
+func NewDetector(devicePath string, source Source) *Detector {
 	return &Detector{
-		devicePath:   devicePath,
-		pollInterval: 2 * time.Second,
+		devicePath: devicePath,
+		source:     source,
+		settle:     2 * time.Second,
 	}
 }
 
-// Start begins monitoring for disc insertion
-// Returns a channel that will receive detected discs
-func (d *Detector) Start(ctx context.Context) <-chan DetectedDisc {
-	ch := make(chan DetectedDisc, 1)
+// Start begins monitoring the drive
+// Returns a channel that receives its state changes; a disc already in the
+// drive is reported as inserted
+func (d *Detector) Start(ctx context.Context) (<-chan Event, error) {
+	states, err := d.source.Watch(ctx, d.devicePath)
+	if err != nil {
+		return nil, err
+	}
 
+	ch := make(chan Event, 1)
 	go func() {
 		defer close(ch)
 
-		lastStatus := CDS_NO_DISC
+		// A disc is reported once it stayed ready for the settle time
+		settle := time.NewTimer(d.settle)
+		settle.Stop()
+		defer settle.Stop()
 
-		ticker := time.NewTicker(d.pollInterval)
-		defer ticker.Stop()
+		send := func(event Event) bool {
+			select {
+			case ch <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
 
+		var last Event
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-ticker.C:
-				status, err := d.checkDriveStatus()
-				if err != nil {
-					// Drive not accessible, continue polling
+
+			case event, ok := <-states:
+				if !ok {
+					return
+				}
+				if event.same(last) {
 					continue
 				}
-
-				// Detect transition from no disc to disc present
-				if lastStatus != CDS_DISC_OK && status == CDS_DISC_OK {
-					// Disc inserted! Wait a moment for it to settle
-					time.Sleep(2 * time.Second)
-
-					// Verify disc is still there
-					status, err = d.checkDriveStatus()
-					if err == nil && status == CDS_DISC_OK {
-						ch <- DetectedDisc{
-							Device: d.devicePath,
-							// Name and DiscType will be populated by MakeMKV scan
-						}
-					}
+				settle.Stop()
+				if event.State == StateDisc {
+					settle.Reset(d.settle)
+				} else if !send(event) {
+					return
 				}
+				last = event
 
-				lastStatus = status
+			case <-settle.C:
+				// Any other state in the meantime stopped the timer
+				if last.State == StateDisc && !send(last) {
+					return
+				}
 			}
 		}
 	}()
 
-	return ch
+	return ch, nil
 }
 
 // checkDriveStatus uses ioctl to check if a disc is present
-func (d *Detector) checkDriveStatus() (int, error) {
+func checkDriveStatus(devicePath string) (int, error) {
 	// Open the device
-	fd, err := syscall.Open(d.devicePath, syscall.O_RDONLY|syscall.O_NONBLOCK, 0)
+	fd, err := syscall.Open(devicePath, syscall.O_RDONLY|syscall.O_NONBLOCK, 0)
 	if err != nil {
 		return CDS_NO_INFO, err
 	}
@@ -101,6 +132,6 @@ func (d *Detector) checkDriveStatus() (int, error) {
 
 // IsDiscPresent checks if a disc is currently in the drive
 func (d *Detector) IsDiscPresent() bool {
-	status, err := d.checkDriveStatus()
-	return err == nil && status == CDS_DISC_OK
+	state, err := ReadState(d.devicePath)
+	return err == nil && state == StateDisc
 }
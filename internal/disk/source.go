@@ -0,0 +1,148 @@
+package disk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// State is the media state of a drive
+type State int
+
+const (
+	StateUnknown  State = iota
+	StateNoDisc         // Tray closed, no disc
+	StateTrayOpen       // Tray open
+	StateNotReady       // Disc inserted, drive still spinning up
+	StateDisc           // Disc ready to be read
+	StateError          // Drive can't be read, e.g. permission denied
+	StateMissing        // Device is gone, e.g. an unplugged USB drive
+)
+
+func (s State) String() string {
+	switch s {
+	case StateNoDisc:
+		return "no_disc"
+	case StateTrayOpen:
+		return "tray_open"
+	case StateNotReady:
+		return "not_ready"
+	case StateDisc:
+		return "disc"
+	case StateError:
+		return "error"
+	case StateMissing:
+		return "missing"
+	default:
+		return "unknown"
+	}
+}
+
+// Source reports the media state of drives
+type Source interface {
+	// Watch sends the drive's current state, then every change of it,
+	// until ctx is done. The channel is closed when watching stops.
+	Watch(ctx context.Context, devicePath string) (<-chan Event, error)
+}
+
+// NewSource returns the state source for a detection method:
+// uevent, poll, or auto for uevent with poll as fallback
+func NewSource(method string) (Source, error) {
+	switch method {
+	case "", "auto":
+		return &fallbackSource{primary: NewUeventSource(), fallback: NewPollSource(2 * time.Second)}, nil
+	case "uevent":
+		return NewUeventSource(), nil
+	case "poll":
+		return NewPollSource(2 * time.Second), nil
+	default:
+		return nil, fmt.Errorf("unknown method %q (use auto, uevent or poll)", method)
+	}
+}
+
+// ReadState asks the drive for its media state
+// If the drive can't be asked, the state is StateError or StateMissing
+func ReadState(devicePath string) (State, error) {
+	status, err := checkDriveStatus(devicePath)
+	if err != nil {
+		if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ENODEV) || errors.Is(err, syscall.ENXIO) {
+			return StateMissing, err
+		}
+		return StateError, err
+	}
+	switch status {
+	case CDS_NO_DISC:
+		return StateNoDisc, nil
+	case CDS_TRAY_OPEN:
+		return StateTrayOpen, nil
+	case CDS_DRIVE_NOT_READY:
+		return StateNotReady, nil
+	case CDS_DISC_OK:
+		return StateDisc, nil
+	default:
+		return StateUnknown, nil
+	}
+}
+
+// PollSource checks drive state with the CDROM_DRIVE_STATUS ioctl at a fixed interval
+// Works everywhere, but only notices changes once per interval
+type PollSource struct {
+	interval time.Duration
+}
+
+func NewPollSource(interval time.Duration) *PollSource {
+	return &PollSource{interval: interval}
+}
+
+func (s *PollSource) Watch(ctx context.Context, devicePath string) (<-chan Event, error) {
+	ch := make(chan Event, 1)
+
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		var last Event
+		for {
+			if event := readEvent(devicePath); !event.same(last) {
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+					return
+				}
+				last = event
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// fallbackSource watches with the primary source, or the fallback if the
+// primary isn't available (e.g. netlink is blocked in a container)
+type fallbackSource struct {
+	primary  Source
+	fallback Source
+}
+
+func (s *fallbackSource) Watch(ctx context.Context, devicePath string) (<-chan Event, error) {
+	if ch, err := s.primary.Watch(ctx, devicePath); err == nil {
+		return ch, nil
+	}
+	return s.fallback.Watch(ctx, devicePath)
+}
+
+// readEvent reads the drive state as an event
+func readEvent(devicePath string) Event {
+	state, err := ReadState(devicePath)
+	return Event{Device: devicePath, State: state, Err: err}
+}
@@ -0,0 +1,294 @@
+package disk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeSource reports states set by hand
+type fakeSource struct {
+	mu       sync.Mutex
+	events   map[string]Event
+	watchers map[string][]chan Event
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		events:   make(map[string]Event),
+		watchers: make(map[string][]chan Event),
+	}
+}
+
+func (s *fakeSource) Watch(ctx context.Context, devicePath string) (<-chan Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan Event, 16)
+	if event, ok := s.events[devicePath]; ok {
+		ch <- event
+	} else {
+		ch <- Event{Device: devicePath, State: StateNoDisc}
+	}
+	s.watchers[devicePath] = append(s.watchers[devicePath], ch)
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		watchers := s.watchers[devicePath]
+		for i, w := range watchers {
+			if w == ch {
+				s.watchers[devicePath] = append(watchers[:i], watchers[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}()
+
+	return ch, nil
+}
+
+// set changes the state of a drive and notifies its watchers
+func (s *fakeSource) set(devicePath string, state State, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event := Event{Device: devicePath, State: state, Err: err}
+	if event.same(s.events[devicePath]) {
+		return
+	}
+	s.events[devicePath] = event
+	for _, ch := range s.watchers[devicePath] {
+		ch <- event
+	}
+}
+
+// failingSource can't watch anything, like a uevent source without netlink
+type failingSource struct{}
+
+func (failingSource) Watch(ctx context.Context, devicePath string) (<-chan Event, error) {
+	return nil, errors.New("netlink not available")
+}
+
+// uevent builds a kernel uevent message from its header and KEY=VALUE pairs
+func uevent(header string, pairs ...string) []byte {
+	msg := []byte(header)
+	for _, p := range pairs {
+		msg = append(msg, 0)
+		msg = append(msg, p...)
+	}
+	return append(msg, 0)
+}
+
+func TestIsDriveUevent(t *testing.T) {
+	const change = "change@/devices/pci0000:00/0000:00:17.0/ata2/host1/target1:0:0/1:0:0:0/block/sr0"
+
+	tests := []struct {
+		name string
+		msg  []byte
+		want bool
+	}{
+		{"media change", uevent(change, "ACTION=change", "SUBSYSTEM=block", "DEVNAME=sr0", "DEVTYPE=disk", "DISK_MEDIA_CHANGE=1"), true},
+		{"eject request", uevent(change, "ACTION=change", "SUBSYSTEM=block", "DEVNAME=sr0", "DISK_EJECT_REQUEST=1"), true},
+		{"full device path", uevent(change, "SUBSYSTEM=block", "DEVNAME=/dev/sr0"), true},
+		{"drive removed", uevent("remove@/devices/usb1/1-1/block/sr0", "ACTION=remove", "SUBSYSTEM=block", "DEVNAME=sr0"), true},
+		{"other drive", uevent("change@/devices/block/sr1", "SUBSYSTEM=block", "DEVNAME=sr1"), false},
+		{"name prefix only", uevent("change@/devices/block/sr01", "SUBSYSTEM=block", "DEVNAME=sr01"), false},
+		{"other subsystem", uevent("change@/devices/scsi_generic/sg0", "SUBSYSTEM=scsi_generic", "DEVNAME=sr0"), false},
+		{"no subsystem", uevent(change, "DEVNAME=sr0"), false},
+		{"malformed pairs", uevent(change, "SUBSYSTEM", "DEVNAME"), false},
+		{"header only", []byte(change), false},
+		{"empty", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDriveUevent(tt.msg, "sr0"); got != tt.want {
+				t.Errorf("isDriveUevent = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// next waits for the detector's next event
+func next(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event from detector")
+		return Event{}
+	}
+}
+
+// none checks the detector reports nothing for a while
+func none(t *testing.T, events <-chan Event) {
+	t.Helper()
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %v", event.State)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func startDetector(t *testing.T, source Source) <-chan Event {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	d := NewDetector("/dev/sr0", source)
+	d.settle = 20 * time.Millisecond
+	events, err := d.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestDetectorTransitions(t *testing.T) {
+	source := newFakeSource()
+	events := startDetector(t, source)
+
+	if event := next(t, events); event.State != StateNoDisc {
+		t.Fatalf("initial state = %v, want no_disc", event.State)
+	}
+
+	// Open the tray, insert a disc, close it
+	source.set("/dev/sr0", StateTrayOpen, nil)
+	if event := next(t, events); event.State != StateTrayOpen {
+		t.Fatalf("state = %v, want tray_open", event.State)
+	}
+	source.set("/dev/sr0", StateNotReady, nil)
+	if event := next(t, events); event.State != StateNotReady {
+		t.Fatalf("state = %v, want not_ready", event.State)
+	}
+	source.set("/dev/sr0", StateDisc, nil)
+	if event := next(t, events); event.State != StateDisc || event.Device != "/dev/sr0" {
+		t.Fatalf("event = %+v, want disc on /dev/sr0", event)
+	}
+
+	// Reporting the same state again is not a change
+	source.set("/dev/sr0", StateDisc, nil)
+	none(t, events)
+
+	// Ejecting reports the tray opening
+	source.set("/dev/sr0", StateTrayOpen, nil)
+	if event := next(t, events); event.State != StateTrayOpen {
+		t.Fatalf("state = %v, want tray_open", event.State)
+	}
+}
+
+func TestDetectorDiscMustSettle(t *testing.T) {
+	source := newFakeSource()
+	events := startDetector(t, source)
+	next(t, events)
+
+	// A disc that's gone again before it settled is never reported
+	source.set("/dev/sr0", StateDisc, nil)
+	source.set("/dev/sr0", StateTrayOpen, nil)
+	if event := next(t, events); event.State != StateTrayOpen {
+		t.Fatalf("state = %v, want tray_open", event.State)
+	}
+	none(t, events)
+}
+
+func TestDetectorRemovedDrive(t *testing.T) {
+	source := newFakeSource()
+	source.set("/dev/sr0", StateDisc, nil)
+	events := startDetector(t, source)
+
+	// A disc already in the drive is reported as inserted
+	if event := next(t, events); event.State != StateDisc {
+		t.Fatalf("initial state = %v, want disc", event.State)
+	}
+
+	source.set("/dev/sr0", StateMissing, syscall.ENOENT)
+	event := next(t, events)
+	if event.State != StateMissing || !errors.Is(event.Err, syscall.ENOENT) {
+		t.Fatalf("event = %+v, want missing with ENOENT", event)
+	}
+
+	// A different error is a change even in the same state
+	source.set("/dev/sr0", StateMissing, syscall.ENXIO)
+	if event := next(t, events); !errors.Is(event.Err, syscall.ENXIO) {
+		t.Fatalf("err = %v, want ENXIO", event.Err)
+	}
+
+	// Plugged back in, without a disc
+	source.set("/dev/sr0", StateNoDisc, nil)
+	if event := next(t, events); event.State != StateNoDisc || event.Err != nil {
+		t.Fatalf("event = %+v, want no_disc", event)
+	}
+}
+
+func TestDetectorStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := NewDetector("/dev/sr0", newFakeSource()).Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next(t, events)
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("event after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("events not closed after cancel")
+	}
+}
+
+func TestFallbackSource(t *testing.T) {
+	fallback := newFakeSource()
+	fallback.set("/dev/sr0", StateTrayOpen, nil)
+	source := &fallbackSource{primary: failingSource{}, fallback: fallback}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := source.Watch(ctx, "/dev/sr0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event := <-ch; event.State != StateTrayOpen {
+		t.Errorf("state = %v, want tray_open from the fallback", event.State)
+	}
+}
+
+func TestNewSource(t *testing.T) {
+	for _, method := range []string{"", "auto", "uevent", "poll"} {
+		if _, err := NewSource(method); err != nil {
+			t.Errorf("NewSource(%q): %v", method, err)
+		}
+	}
+	if _, err := NewSource("fake"); err == nil {
+		t.Error("NewSource accepted an unknown method")
+	}
+}
+
+func TestEventSame(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Event
+		want bool
+	}{
+		{"same state", Event{State: StateDisc}, Event{State: StateDisc}, true},
+		{"different state", Event{State: StateDisc}, Event{State: StateNoDisc}, false},
+		{"error appears", Event{State: StateError}, Event{State: StateError, Err: syscall.EACCES}, false},
+		{"same error", Event{State: StateError, Err: syscall.EACCES}, Event{State: StateError, Err: syscall.EACCES}, true},
+		{"different error", Event{State: StateMissing, Err: syscall.ENOENT}, Event{State: StateMissing, Err: syscall.ENODEV}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.same(tt.b); got != tt.want {
+				t.Errorf("same = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
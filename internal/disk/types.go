@@ -1,6 +1,7 @@
 package disk
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -22,6 +23,18 @@ func (dt DiscType) String() string {
 	}
 }
 
+// ParseDiscType parses a user-supplied disc type name
+func ParseDiscType(s string) (DiscType, error) {
+	switch strings.ToLower(s) {
+	case "bluray", "blu-ray", "br":
+		return DiscTypeBluRay, nil
+	case "dvd":
+		return DiscTypeDVD, nil
+	default:
+		return DiscTypeDVD, fmt.Errorf("invalid disc type: %s (use bluray or dvd)", s)
+	}
+}
+
 type DetectedDisc struct {
 	Device   string
 	Name     string
@@ -0,0 +1,146 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// UeventSource listens for kernel uevents on a netlink socket and reads the
+// drive state when the kernel reports a media change, so insertion, removal
+// and tray changes are noticed immediately without polling
+type UeventSource struct {
+	// While a disc spins up no further event arrives, so a drive that
+	// isn't ready yet is checked again at this interval
+	settleInterval time.Duration
+	// Drives whose kernel doesn't poll for media changes send no events
+	// at all, so the state is still checked now and then
+	recheckInterval time.Duration
+}
+
+func NewUeventSource() *UeventSource {
+	return &UeventSource{
+		settleInterval:  time.Second,
+		recheckInterval: 30 * time.Second,
+	}
+}
+
+func (s *UeventSource) Watch(ctx context.Context, devicePath string) (<-chan Event, error) {
+	// Match events by kernel name, e.g. /dev/cdrom -> sr0
+	name := devicePath
+	if resolved, err := filepath.EvalSymlinks(devicePath); err == nil {
+		name = resolved
+	}
+	name = filepath.Base(name)
+
+	sock, err := openUevents()
+	if err != nil {
+		return nil, err
+	}
+
+	// Media changes of this drive, from the socket reader
+	changed := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := sock.Read(buf)
+			if err != nil {
+				// Closed on cancel
+				return
+			}
+			if isDriveUevent(buf[:n], name) {
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	ch := make(chan Event, 1)
+	go func() {
+		defer close(ch)
+		defer sock.Close()
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		var last Event
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			case <-timer.C:
+			}
+
+			event := readEvent(devicePath)
+			if !event.same(last) {
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+					return
+				}
+				last = event
+			}
+
+			// An unreadable drive is retried as often, so it's noticed when it comes back
+			next := s.recheckInterval
+			if event.State == StateNotReady || event.Err != nil {
+				next = s.settleInterval
+			}
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(next)
+		}
+	}()
+
+	return ch, nil
+}
+
+// openUevents opens a netlink socket subscribed to kernel uevents
+func openUevents() (*os.File, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uevent socket: %w", err)
+	}
+	// Group 1 is the kernel's own broadcast, before udev processed it
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: 1}); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to bind uevent socket: %w", err)
+	}
+	// Non-blocking, so reads go through the runtime poller and Close interrupts them
+	return os.NewFile(uintptr(fd), "uevent"), nil
+}
+
+// isDriveUevent reports whether a uevent message concerns the block device name
+// Messages are "ACTION@DEVPATH" followed by NUL separated KEY=VALUE pairs
+func isDriveUevent(msg []byte, name string) bool {
+	fields := bytes.Split(msg, []byte{0})
+	if len(fields) < 2 {
+		return false
+	}
+
+	var subsystem, devname string
+	for _, f := range fields[1:] {
+		key, value, ok := bytes.Cut(f, []byte("="))
+		if !ok {
+			continue
+		}
+		switch string(key) {
+		case "SUBSYSTEM":
+			subsystem = string(value)
+		case "DEVNAME":
+			devname = filepath.Base(string(value))
+		}
+	}
+	return subsystem == "block" && devname == name
+}
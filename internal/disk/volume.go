@@ -0,0 +1,109 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+const sectorSize = 2048
+
+// VolumeID reads the file system identity of the disc in a drive
+// DVDs and Blu-rays carry UDF, whose volume set identifier starts with a
+// unique value written at mastering; older discs fall back to the ISO 9660
+// volume identifier and creation time. Returns "" if neither is found.
+func VolumeID(devicePath string) (string, error) {
+	f, err := os.Open(devicePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open device: %w", err)
+	}
+	defer f.Close()
+
+	if id, err := udfVolumeID(f); err == nil && id != "" {
+		return id, nil
+	}
+
+	return isoVolumeID(f)
+}
+
+// udfVolumeID follows the anchor at sector 256 to the Primary Volume Descriptor
+func udfVolumeID(r io.ReaderAt) (string, error) {
+	anchor := make([]byte, sectorSize)
+	if _, err := r.ReadAt(anchor, 256*sectorSize); err != nil {
+		return "", fmt.Errorf("failed to read UDF anchor: %w", err)
+	}
+	if binary.LittleEndian.Uint16(anchor[0:2]) != 2 {
+		return "", nil
+	}
+
+	// Main Volume Descriptor Sequence extent
+	length := binary.LittleEndian.Uint32(anchor[16:20])
+	location := binary.LittleEndian.Uint32(anchor[20:24])
+
+	desc := make([]byte, sectorSize)
+	for i := uint32(0); i < length/sectorSize && i < 64; i++ {
+		if _, err := r.ReadAt(desc, int64(location+i)*sectorSize); err != nil {
+			return "", fmt.Errorf("failed to read UDF descriptor: %w", err)
+		}
+		switch binary.LittleEndian.Uint16(desc[0:2]) {
+		case 1: // Primary Volume Descriptor
+			volume := dstring(desc[24:56])
+			volumeSet := dstring(desc[72:200])
+			if volume == "" && volumeSet == "" {
+				return "", nil
+			}
+			return "udf:" + volume + ":" + volumeSet, nil
+		case 8: // Terminating Descriptor
+			return "", nil
+		}
+	}
+	return "", nil
+}
+
+// isoVolumeID reads the ISO 9660 Primary Volume Descriptor at sector 16
+func isoVolumeID(r io.ReaderAt) (string, error) {
+	pvd := make([]byte, sectorSize)
+	if _, err := r.ReadAt(pvd, 16*sectorSize); err != nil {
+		return "", fmt.Errorf("failed to read volume descriptor: %w", err)
+	}
+	if pvd[0] != 1 || string(pvd[1:6]) != "CD001" {
+		return "", nil
+	}
+
+	volume := strings.TrimSpace(string(pvd[40:72]))
+	created := string(pvd[813:829])
+	if strings.Trim(created, "0 \x00") == "" {
+		created = "" // Not recorded
+	}
+	if volume == "" && created == "" {
+		return "", nil
+	}
+	return "iso:" + volume + ":" + created, nil
+}
+
+// dstring decodes a UDF d-string: a compression ID, the characters
+// (8 or 16 bits each) and the used length in the last byte
+func dstring(b []byte) string {
+	n := int(b[len(b)-1])
+	if n == 0 || n > len(b)-1 {
+		return ""
+	}
+	data := b[1:n]
+
+	switch b[0] {
+	case 8:
+		return strings.TrimSpace(string(bytes.TrimRight(data, "\x00")))
+	case 16:
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(data[2*i:])
+		}
+		return strings.TrimSpace(strings.TrimRight(string(utf16.Decode(units)), "\x00"))
+	default:
+		return ""
+	}
+}
@@ -1,10 +1,14 @@
 package encode
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -18,29 +22,135 @@ import (
 
 type HandBrake struct {
 	config  *config.Config
+	threads int // Encoder threads, 0 leaves it to the encoder
 	cmd     *exec.Cmd
 	paused  bool
 	pauseMu sync.Mutex
+
+	lowPriority bool // Encodes run at the lowest CPU and I/O priority
+	niced       bool // The running process is at the lowest priority
+	canceled    bool // The item was canceled, also between its segments
 }
 
-func NewHandBrake(cfg *config.Config) *HandBrake {
+// errCanceled is returned when an item is canceled between two processes
+var errCanceled = errors.New("encode canceled")
+
+func NewHandBrake(cfg *config.Config, threads int) *HandBrake {
 	return &HandBrake{
-		config: cfg,
+		config:  cfg,
+		threads: threads,
 	}
 }
 
+// prepare clears the pause and cancel of the previous item
+func (hb *HandBrake) prepare() {
+	hb.pauseMu.Lock()
+	defer hb.pauseMu.Unlock()
+
+	hb.paused = false
+	hb.canceled = false
+}
+
 // Encode encodes a video file using HandBrake
 func (hb *HandBrake) Encode(ctx context.Context, item *QueueItem, progressCh chan<- float64, logCh chan<- string) error {
-	args := hb.buildArgs(item)
+	return hb.run(ctx, hb.buildArgs(item, item.DestPath), progressCh, logCh)
+}
+
+// EncodeSegment encodes one segment of the source to output
+func (hb *HandBrake) EncodeSegment(ctx context.Context, item *QueueItem, segment Segment, output string, progressCh chan<- float64, logCh chan<- string) error {
+	return hb.run(ctx, append(hb.buildArgs(item, output), segment.args()...), progressCh, logCh)
+}
+
+// PlanSegments scans the source and splits it into segments of about
+// handbrake.segment_minutes, or returns nil if it is too short to split
+func (hb *HandBrake) PlanSegments(ctx context.Context, source string) ([]Segment, error) {
+	// --scan prints the titles to stderr and exits without encoding
+	output, err := exec.CommandContext(ctx, hb.config.HandBrake.BinaryPath, "--scan", "-i", source).CombinedOutput()
+	duration, chapters := parseScan(strings.Split(string(output), "\n"))
+	if duration == 0 {
+		if err != nil {
+			return nil, fmt.Errorf("HandBrakeCLI scan failed: %w", err)
+		}
+		return nil, fmt.Errorf("no duration in HandBrakeCLI scan")
+	}
+
+	return planSegments(duration, chapters, float64(hb.config.HandBrake.SegmentMinutes*60)), nil
+}
+
+// Join losslessly concatenates encoded segments into output with mkvmerge
+func (hb *HandBrake) Join(ctx context.Context, parts []string, output string) error {
+	args := []string{"-o", output, parts[0]}
+	for _, part := range parts[1:] {
+		args = append(args, "+", part)
+	}
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, hb.config.HandBrake.MkvmergePath, args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := hb.start(cmd, cmd.Start); err != nil {
+		return err
+	}
+	err := hb.wait(cmd)
+
+	// mkvmerge exits with 1 on warnings, the file is still complete
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		err = nil
+	}
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		return fmt.Errorf("mkvmerge failed: %w: %s", err, lines[len(lines)-1])
+	}
+	return nil
+}
+
+// start starts cmd as the running process of the item, unless the item
+// was canceled, and brings it to the current pause and priority
+func (hb *HandBrake) start(cmd *exec.Cmd, start func() error) error {
+	hb.pauseMu.Lock()
+	defer hb.pauseMu.Unlock()
+
+	if hb.canceled {
+		return errCanceled
+	}
+	if err := start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", filepath.Base(cmd.Path), err)
+	}
+	hb.cmd = cmd
+
+	hb.niced = hb.lowPriority && setProcessPriority(cmd.Process.Pid, true) == nil
+	// Paused between two segments
+	if hb.paused {
+		cmd.Process.Signal(syscall.SIGSTOP)
+	}
+	return nil
+}
+
+// wait waits for the running process to exit
+func (hb *HandBrake) wait(cmd *exec.Cmd) error {
+	err := cmd.Wait()
 
+	// Don't signal the PID once it may belong to another process
 	hb.pauseMu.Lock()
-	hb.cmd = exec.CommandContext(ctx, hb.config.HandBrake.BinaryPath, args...)
+	hb.cmd = nil
 	hb.pauseMu.Unlock()
 
+	return err
+}
+
+// run runs HandBrakeCLI and reports its progress
+func (hb *HandBrake) run(ctx context.Context, args []string, progressCh chan<- float64, logCh chan<- string) error {
+	cmd := exec.CommandContext(ctx, hb.config.HandBrake.BinaryPath, args...)
+
 	// Start with a PTY to get unbuffered output
-	ptmx, err := pty.Start(hb.cmd)
+	var ptmx *os.File
+	err := hb.start(cmd, func() (err error) {
+		ptmx, err = pty.Start(cmd)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to start HandBrakeCLI with PTY: %w", err)
+		return err
 	}
 	defer ptmx.Close()
 
@@ -127,7 +237,7 @@ func (hb *HandBrake) Encode(ctx context.Context, item *QueueItem, progressCh cha
 	}()
 
 	// Wait for command to complete
-	err = hb.cmd.Wait()
+	err = hb.wait(cmd)
 	<-done // Wait for reader to finish
 
 	if err != nil {
@@ -145,7 +255,7 @@ func (hb *HandBrake) Encode(ctx context.Context, item *QueueItem, progressCh cha
 }
 
 // buildArgs constructs HandBrake command-line arguments based on profile
-func (hb *HandBrake) buildArgs(item *QueueItem) []string {
+func (hb *HandBrake) buildArgs(item *QueueItem, output string) []string {
 	var profile config.HandBrakeProfile
 
 	// Select profile based on disc type
@@ -157,7 +267,7 @@ func (hb *HandBrake) buildArgs(item *QueueItem) []string {
 
 	args := []string{
 		"-i", item.SourcePath,
-		"-o", item.DestPath,
+		"-o", output,
 	}
 
 	// Use preset file if specified
@@ -192,20 +302,24 @@ func (hb *HandBrake) buildArgs(item *QueueItem) []string {
 
 	// Set thread count if specified (0 = auto)
 	// For SVT-AV1 and other encoders, pass threads as encoder options
-	if hb.config.HandBrake.Threads > 0 {
-		args = append(args, "--encopts", fmt.Sprintf("threads=%d", hb.config.HandBrake.Threads))
+	if hb.threads > 0 {
+		args = append(args, "--encopts", fmt.Sprintf("threads=%d", hb.threads))
 	}
 
 	return args
 }
 
 // Pause pauses the HandBrake process
+// Between two segments, the next one starts paused
 func (hb *HandBrake) Pause() error {
 	hb.pauseMu.Lock()
 	defer hb.pauseMu.Unlock()
 
-	if hb.cmd != nil && hb.cmd.Process != nil && !hb.paused {
-		hb.paused = true
+	if hb.paused {
+		return nil
+	}
+	hb.paused = true
+	if hb.cmd != nil && hb.cmd.Process != nil {
 		return hb.cmd.Process.Signal(syscall.SIGSTOP)
 	}
 
@@ -217,19 +331,45 @@ func (hb *HandBrake) Resume() error {
 	hb.pauseMu.Lock()
 	defer hb.pauseMu.Unlock()
 
-	if hb.cmd != nil && hb.cmd.Process != nil && hb.paused {
-		hb.paused = false
+	if !hb.paused {
+		return nil
+	}
+	hb.paused = false
+	if hb.cmd != nil && hb.cmd.Process != nil {
 		return hb.cmd.Process.Signal(syscall.SIGCONT)
 	}
 
 	return nil
 }
 
+// SetLowPriority lowers the CPU and I/O priority of the running and
+// future encodes, or restores it
+// Future encodes always follow; the running one only changes when the
+// kernel allows it, and calling again retries
+func (hb *HandBrake) SetLowPriority(low bool) error {
+	hb.pauseMu.Lock()
+	defer hb.pauseMu.Unlock()
+
+	hb.lowPriority = low
+	if hb.cmd == nil || hb.cmd.Process == nil || hb.niced == low {
+		return nil
+	}
+
+	if err := setProcessPriority(hb.cmd.Process.Pid, low); err != nil {
+		return err
+	}
+	hb.niced = low
+
+	return nil
+}
+
 // Cancel cancels the encoding process
 func (hb *HandBrake) Cancel() error {
 	hb.pauseMu.Lock()
 	defer hb.pauseMu.Unlock()
 
+	hb.canceled = true
+
 	if hb.cmd != nil && hb.cmd.Process != nil {
 		return hb.cmd.Process.Kill()
 	}
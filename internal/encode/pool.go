@@ -0,0 +1,66 @@
+package encode
+
+import (
+	"context"
+	"fmt"
+)
+
+// Pool runs several workers on one queue, each with its own HandBrake
+// instance, and routes control commands to the worker encoding the item
+type Pool struct {
+	workers   []*Worker
+	controls  []chan ItemControl
+	controlCh <-chan ItemControl
+	logCh     chan<- string
+}
+
+// NewPool creates a worker per HandBrake instance
+// Raw HandBrake output goes to outputCh, the workers' own messages to logCh
+func NewPool(queue *Queue, handbrakes []*HandBrake, progressCh chan<- ProgressUpdate, controlCh <-chan ItemControl, logCh, outputCh chan<- string) *Pool {
+	p := &Pool{controlCh: controlCh, logCh: logCh}
+	for _, hb := range handbrakes {
+		ch := make(chan ItemControl, 10)
+		p.controls = append(p.controls, ch)
+		p.workers = append(p.workers, NewWorker(queue, hb, progressCh, ch, logCh, outputCh))
+	}
+	return p
+}
+
+// Run starts the workers and forwards control commands until ctx is done
+func (p *Pool) Run(ctx context.Context) {
+	for _, w := range p.workers {
+		go w.Run(ctx)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ctrl := <-p.controlCh:
+			p.dispatch(ctx, ctrl)
+		}
+	}
+}
+
+// dispatch sends a command to every worker, or to the one encoding its item
+// Scheduling only sends commands when its state changes, so a command must
+// never be dropped; workers read their commands even while encoding
+func (p *Pool) dispatch(ctx context.Context, ctrl ItemControl) {
+	for i, w := range p.workers {
+		if ctrl.ItemID != "" && w.Current() != ctrl.ItemID {
+			continue
+		}
+		select {
+		case p.controls[i] <- ctrl:
+		case <-ctx.Done():
+			return
+		}
+		if ctrl.ItemID != "" {
+			return
+		}
+	}
+
+	if ctrl.ItemID != "" && p.logCh != nil {
+		p.logCh <- fmt.Sprintf("Item %s is not being encoded, ignoring command", ctrl.ItemID)
+	}
+}
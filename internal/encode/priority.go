@@ -0,0 +1,52 @@
+package encode
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+	ioprioClassIdle  = 3 // Only gets disk time nobody else wants
+)
+
+// setProcessPriority lowers a process to the lowest CPU and I/O priority,
+// or brings it back to the priority of mkvauto itself
+// Threads are separate tasks to the kernel, so each is changed on its own
+func setProcessPriority(pid int, low bool) error {
+	// Getpriority returns 20 - nice
+	prio, err := syscall.Getpriority(syscall.PRIO_PROCESS, 0)
+	if err != nil {
+		return fmt.Errorf("failed to read own priority: %w", err)
+	}
+	nice, ioprio := 20-prio, 0 // I/O class none follows the CPU priority
+	if low {
+		nice, ioprio = 19, ioprioClassIdle<<ioprioClassShift
+	}
+
+	tids := []int{pid}
+	if tasks, err := os.ReadDir(fmt.Sprintf("/proc/%d/task", pid)); err == nil {
+		tids = tids[:0]
+		for _, task := range tasks {
+			if tid, err := strconv.Atoi(task.Name()); err == nil {
+				tids = append(tids, tid)
+			}
+		}
+	}
+
+	for _, tid := range tids {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, nice); err != nil {
+			if !low && err == syscall.EACCES {
+				return fmt.Errorf("raising the encoder priority again needs CAP_SYS_NICE: %w", err)
+			}
+			return fmt.Errorf("failed to set niceness: %w", err)
+		}
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), uintptr(ioprio)); errno != 0 {
+			return fmt.Errorf("failed to set I/O priority: %w", errno)
+		}
+	}
+	return nil
+}
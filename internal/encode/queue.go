@@ -1,6 +1,9 @@
 package encode
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"sync"
 	"time"
 
@@ -47,12 +50,42 @@ type QueueItem struct {
 	StartedAt   *time.Time       `json:"started_at,omitempty"`
 	CompletedAt *time.Time       `json:"completed_at,omitempty"`
 	Error       string           `json:"error,omitempty"`
+	Priority    int              `json:"priority,omitempty"`    // Higher is encoded sooner
+	SourceSize  int64            `json:"source_size,omitempty"` // Bytes, stands in for the encode time
+	Segments    []Segment        `json:"segments,omitempty"`    // Parts encoded on their own, see handbrake.segment_minutes
 }
 
+// SegmentCount returns how many segments of the item are done, of how many
+// An item encoded in one go has none
+func (item *QueueItem) SegmentCount() (int, int) {
+	done := 0
+	for _, s := range item.Segments {
+		if s.Done {
+			done++
+		}
+	}
+	return done, len(item.Segments)
+}
+
+// Policy decides where new items are placed among the queued ones
+// Items of higher priority always go first; the policy orders items of
+// equal priority. The queue is encoded in order, so manual moves stick.
+type Policy string
+
+const (
+	PolicyFIFO          Policy = "fifo"           // In the order they were added
+	PolicyShortestFirst Policy = "shortest_first" // Smallest source file first
+	PolicyDVDFirst      Policy = "dvd_first"      // DVDs before Blu-rays
+)
+
+// ErrNotQueued is returned when reordering an item that isn't waiting
+var ErrNotQueued = errors.New("item is not queued")
+
 type Queue struct {
 	items       []*QueueItem
 	mu          sync.RWMutex
 	persistence *StatePersistence
+	policy      Policy
 }
 
 func NewQueue(statePath string) *Queue {
@@ -76,10 +109,12 @@ func (q *Queue) LoadState() error {
 	q.items = items
 
 	// Reset any items stuck in "encoding" state from interrupted sessions
+	// No worker runs yet, so none of them can still be encoding
+	// Segmented items keep their done segments and continue after them
 	for _, item := range q.items {
 		if item.Status == StatusEncoding {
 			item.Status = StatusQueued
-			item.Progress = 0
+			item.Progress = segmentProgress(item.Segments)
 			item.StartedAt = nil
 		}
 	}
@@ -98,17 +133,162 @@ func (q *Queue) SaveState() error {
 	return q.persistence.Save(q.items)
 }
 
-// Add adds a new item to the queue
+// SetPolicy sets how new items are placed in the queue
+func (q *Queue) SetPolicy(policy Policy) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.policy = policy
+}
+
+// Add adds a new item to the queue, ahead of queued items it should be
+// encoded before
 func (q *Queue) Add(item *QueueItem) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	q.items = append(q.items, item)
+	if item.SourceSize == 0 {
+		if info, err := os.Stat(item.SourcePath); err == nil {
+			item.SourceSize = info.Size()
+		}
+	}
+	q.insert(item)
 
 	// Save to disk
 	return q.persistence.Save(q.items)
 }
 
+// insert places an item before the first queued item it goes ahead of
+func (q *Queue) insert(item *QueueItem) {
+	for i, other := range q.items {
+		if other.Status == StatusQueued && q.before(item, other) {
+			q.items = append(q.items[:i], append([]*QueueItem{item}, q.items[i:]...)...)
+			return
+		}
+	}
+	q.items = append(q.items, item)
+}
+
+// before reports whether a should be encoded before b
+func (q *Queue) before(a, b *QueueItem) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	switch q.policy {
+	case PolicyShortestFirst:
+		return a.SourceSize > 0 && b.SourceSize > 0 && a.SourceSize < b.SourceSize
+	case PolicyDVDFirst:
+		return a.DiscType == disk.DiscTypeDVD && b.DiscType == disk.DiscTypeBluRay
+	default:
+		return false
+	}
+}
+
+// SetPriority changes the priority of a queued item and moves it to its
+// place among the queued items
+func (q *Queue) SetPriority(id string, priority int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i, err := q.queuedIndex(id)
+	if err != nil {
+		return err
+	}
+
+	item := q.items[i]
+	item.Priority = priority
+	q.items = append(q.items[:i], q.items[i+1:]...)
+	q.insert(item)
+
+	return q.persistence.Save(q.items)
+}
+
+// MoveUp swaps a queued item with the queued item before it
+func (q *Queue) MoveUp(id string) error {
+	return q.move(id, -1)
+}
+
+// MoveDown swaps a queued item with the queued item after it
+func (q *Queue) MoveDown(id string) error {
+	return q.move(id, 1)
+}
+
+// move swaps a queued item with its queued neighbour in direction dir
+func (q *Queue) move(id string, dir int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i, err := q.queuedIndex(id)
+	if err != nil {
+		return err
+	}
+
+	for j := i + dir; j >= 0 && j < len(q.items); j += dir {
+		if q.items[j].Status == StatusQueued {
+			q.items[i], q.items[j] = q.items[j], q.items[i]
+			return q.persistence.Save(q.items)
+		}
+	}
+
+	// Already first or last
+	return nil
+}
+
+// MoveToTop makes a queued item the next one to be encoded
+func (q *Queue) MoveToTop(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i, err := q.queuedIndex(id)
+	if err != nil {
+		return err
+	}
+
+	item := q.items[i]
+	q.items = append(q.items[:i], q.items[i+1:]...)
+
+	// Raise its priority to the highest queued one, so items added later
+	// don't overtake it unless they have a higher priority still
+	first := len(q.items)
+	for j, other := range q.items {
+		if other.Status == StatusQueued {
+			first = min(first, j)
+			item.Priority = max(item.Priority, other.Priority)
+		}
+	}
+	q.items = append(q.items[:first], append([]*QueueItem{item}, q.items[first:]...)...)
+
+	return q.persistence.Save(q.items)
+}
+
+// queuedIndex returns the position of a queued item
+func (q *Queue) queuedIndex(id string) (int, error) {
+	for i, item := range q.items {
+		if item.ID == id {
+			if item.Status != StatusQueued {
+				return 0, fmt.Errorf("%w: %s is %s", ErrNotQueued, item.TitleName, item.Status)
+			}
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("queue item not found: %s", id)
+}
+
+// GetQueued returns the items waiting to be encoded, in the order they will be
+func (q *Queue) GetQueued() []*QueueItem {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	var queued []*QueueItem
+	for _, item := range q.items {
+		if item.Status == StatusQueued {
+			queued = append(queued, item)
+		}
+	}
+
+	return queued
+}
+
 // HasSourcePath checks if an item with the given source path already exists in the queue
 func (q *Queue) HasSourcePath(sourcePath string) bool {
 	q.mu.RLock()
@@ -122,13 +302,33 @@ func (q *Queue) HasSourcePath(sourcePath string) bool {
 	return false
 }
 
-// GetNext returns the next queued item, or nil if none available
-func (q *Queue) GetNext() *QueueItem {
+// Claim marks the next queued item as encoding and returns it, or nil if
+// none is available. Workers share the queue, so taking an item must not
+// be split into a lookup and a status change.
+func (q *Queue) Claim() *QueueItem {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
 	for _, item := range q.items {
 		if item.Status == StatusQueued {
+			now := time.Now()
+			item.Status = StatusEncoding
+			item.StartedAt = &now
+			q.persistence.Save(q.items)
+			return item
+		}
+	}
+
+	return nil
+}
+
+// Get returns the item with the given ID, or nil
+func (q *Queue) Get(id string) *QueueItem {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	for _, item := range q.items {
+		if item.ID == id {
 			return item
 		}
 	}
@@ -136,6 +336,69 @@ func (q *Queue) GetNext() *QueueItem {
 	return nil
 }
 
+// Segments returns a copy of the segments of an item
+func (q *Queue) Segments(id string) []Segment {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	for _, item := range q.items {
+		if item.ID == id {
+			return append([]Segment(nil), item.Segments...)
+		}
+	}
+
+	return nil
+}
+
+// SetSegments records how an item is split for encoding
+func (q *Queue) SetSegments(id string, segments []Segment) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, item := range q.items {
+		if item.ID == id {
+			item.Segments = segments
+			return q.persistence.Save(q.items)
+		}
+	}
+
+	return nil
+}
+
+// CompleteSegment marks a segment of an item as encoded, so it isn't
+// encoded again after a restart
+func (q *Queue) CompleteSegment(id string, index int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, item := range q.items {
+		if item.ID == id && index < len(item.Segments) {
+			item.Segments[index].Done = true
+			item.Progress = segmentProgress(item.Segments)
+			return q.persistence.Save(q.items)
+		}
+	}
+
+	return nil
+}
+
+// ResetSegment marks a segment of an item as not encoded, e.g. because its
+// file is gone, so it's encoded again after a restart too
+func (q *Queue) ResetSegment(id string, index int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, item := range q.items {
+		if item.ID == id && index < len(item.Segments) {
+			item.Segments[index].Done = false
+			item.Progress = segmentProgress(item.Segments)
+			return q.persistence.Save(q.items)
+		}
+	}
+
+	return nil
+}
+
 // UpdateProgress updates the progress of an item
 func (q *Queue) UpdateProgress(id string, progress float64) error {
 	q.mu.Lock()
@@ -219,18 +482,19 @@ func (q *Queue) GetAll() []*QueueItem {
 	return items
 }
 
-// GetCurrent returns the currently encoding item, if any
-func (q *Queue) GetCurrent() *QueueItem {
+// GetActive returns the items being encoded, in queue order
+func (q *Queue) GetActive() []*QueueItem {
 	q.mu.RLock()
 	defer q.mu.RUnlock()
 
+	var active []*QueueItem
 	for _, item := range q.items {
 		if item.Status == StatusEncoding {
-			return item
+			active = append(active, item)
 		}
 	}
 
-	return nil
+	return active
 }
 
 // ClearCompleted removes completed and failed items from the queue
@@ -242,6 +506,8 @@ func (q *Queue) ClearCompleted() error {
 	for _, item := range q.items {
 		if item.Status != StatusComplete && item.Status != StatusFailed {
 			filtered = append(filtered, item)
+		} else {
+			removeParts(item)
 		}
 	}
 
@@ -249,16 +515,17 @@ func (q *Queue) ClearCompleted() error {
 	return q.persistence.Save(q.items)
 }
 
-// RetryFailed resets all failed and stuck encoding items to queued status for retry
+// RetryFailed resets all failed items to queued status for retry
+// Items still marked encoding are left alone: a worker may be encoding
+// them, and ones stuck from an interrupted session are requeued by LoadState
 func (q *Queue) RetryFailed() error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
 	for _, item := range q.items {
-		// Reset failed items and stuck encoding items (from interrupted sessions)
-		if item.Status == StatusFailed || item.Status == StatusEncoding {
+		if item.Status == StatusFailed {
 			item.Status = StatusQueued
-			item.Progress = 0
+			item.Progress = segmentProgress(item.Segments)
 			item.Error = ""
 			item.StartedAt = nil
 			item.CompletedAt = nil
@@ -277,6 +544,8 @@ func (q *Queue) Remove(id string) error {
 	for _, item := range q.items {
 		if item.ID != id {
 			filtered = append(filtered, item)
+		} else {
+			removeParts(item)
 		}
 	}
 
@@ -0,0 +1,254 @@
+package encode
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Progress reported for a segmented item until its segments are joined
+const maxSegmentedProgress = 99.9
+
+// Segment is a part of the source that is encoded on its own, so an
+// interrupted encode only has to redo the segment that was in flight
+type Segment struct {
+	Chapters string  `json:"chapters,omitempty"` // HandBrake chapter range, e.g. "4-6"; empty cuts by time
+	Start    float64 `json:"start,omitempty"`    // Seconds into the source, when cut by time
+	Length   float64 `json:"length"`             // Seconds, also weighs the segment in the progress
+	Done     bool    `json:"done,omitempty"`
+}
+
+// args selects the segment from the source
+// Time cuts aren't frame-accurate, so chapter ranges are used whenever the
+// source has chapters
+func (s Segment) args() []string {
+	if s.Chapters != "" {
+		return []string{"--chapters", s.Chapters}
+	}
+	// HandBrake counts --stop-at from --start-at
+	var args []string
+	if s.Start > 0 {
+		args = append(args, "--start-at", fmt.Sprintf("seconds:%g", s.Start))
+	}
+	return append(args, "--stop-at", fmt.Sprintf("seconds:%g", s.Length))
+}
+
+// segmentProgress returns how much of the source is in done segments, in %
+func segmentProgress(segments []Segment) float64 {
+	var done, total float64
+	for _, s := range segments {
+		total += s.Length
+		if s.Done {
+			done += s.Length
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return done / total * 100
+}
+
+// planSegments splits a source into segments of about target seconds, at
+// chapter boundaries when it has chapters and by time otherwise
+// Returns nil when the source is too short to be worth splitting
+func planSegments(duration float64, chapters []float64, target float64) []Segment {
+	if target <= 0 || duration < target*1.5 {
+		return nil
+	}
+
+	var segments []Segment
+	if len(chapters) > 1 {
+		first, length := 1, 0.0
+		for i, d := range chapters {
+			length += d
+			if length < target && i < len(chapters)-1 {
+				continue
+			}
+			chapterRange := strconv.Itoa(first)
+			if i+1 > first {
+				chapterRange = fmt.Sprintf("%d-%d", first, i+1)
+			}
+			segments = append(segments, Segment{Chapters: chapterRange, Length: length})
+			first, length = i+2, 0
+		}
+	} else {
+		for start := 0.0; start < duration; start += target {
+			segments = append(segments, Segment{Start: start, Length: min(target, duration-start)})
+		}
+		// Durations are whole seconds, so the last segment runs a second
+		// longer to keep the end of the source
+		segments[len(segments)-1].Length++
+	}
+
+	// A short tail joins the segment before it
+	if n := len(segments); n > 1 && segments[n-1].Length < target/2 {
+		last, prev := segments[n-1], &segments[n-2]
+		if prev.Chapters != "" {
+			from, _, _ := strings.Cut(prev.Chapters, "-")
+			to := last.Chapters[strings.LastIndex(last.Chapters, "-")+1:]
+			prev.Chapters = from + "-" + to
+		}
+		prev.Length += last.Length
+		segments = segments[:n-1]
+	}
+
+	if len(segments) < 2 {
+		return nil
+	}
+	return segments
+}
+
+var (
+	scanDurationRegex = regexp.MustCompile(`^\s*\+ duration: (\d+):(\d+):(\d+)`)
+	scanChapterRegex  = regexp.MustCompile(`^\s*\+ \d+: duration (\d+):(\d+):(\d+)`)
+)
+
+// parseScan reads the duration and chapter lengths of the first title from
+// HandBrakeCLI --scan output, all in seconds
+func parseScan(lines []string) (float64, []float64) {
+	var duration float64
+	var chapters []float64
+	for _, line := range lines {
+		if m := scanChapterRegex.FindStringSubmatch(line); m != nil {
+			chapters = append(chapters, clockSeconds(m[1:]))
+		} else if m := scanDurationRegex.FindStringSubmatch(line); m != nil {
+			if duration > 0 {
+				break // Next title
+			}
+			duration = clockSeconds(m[1:])
+		}
+	}
+	return duration, chapters
+}
+
+// clockSeconds converts hours, minutes and seconds to seconds
+func clockSeconds(hms []string) float64 {
+	h, _ := strconv.Atoi(hms[0])
+	m, _ := strconv.Atoi(hms[1])
+	s, _ := strconv.Atoi(hms[2])
+	return float64(h*3600 + m*60 + s)
+}
+
+// partsDir is where the segments of an item are encoded to, a hidden
+// directory next to the destination so the joined file is only renamed
+func partsDir(item *QueueItem) string {
+	return filepath.Join(filepath.Dir(item.DestPath), "."+filepath.Base(item.DestPath)+".parts")
+}
+
+// removeParts deletes the segments encoded for an item so far
+func removeParts(item *QueueItem) {
+	if len(item.Segments) > 0 {
+		os.RemoveAll(partsDir(item))
+	}
+}
+
+// segments returns the segments of an item, planning them when it is
+// first encoded. Nil encodes the item in one go.
+func (w *Worker) segments(ctx context.Context, item *QueueItem) []Segment {
+	if w.handbrake.config.HandBrake.SegmentMinutes == 0 {
+		return nil
+	}
+	if segments := w.queue.Segments(item.ID); len(segments) > 0 {
+		return segments
+	}
+
+	// mkvmerge only writes Matroska
+	if filepath.Ext(item.DestPath) != ".mkv" {
+		return nil
+	}
+
+	segments, err := w.handbrake.PlanSegments(ctx, item.SourcePath)
+	if err != nil {
+		if w.logCh != nil {
+			w.logCh <- fmt.Sprintf("Could not split %s into segments, encoding it in one go: %v", item.TitleName, err)
+		}
+		return nil
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+
+	w.queue.SetSegments(item.ID, segments)
+	if w.logCh != nil {
+		w.logCh <- fmt.Sprintf("Encoding %s in %d segments", item.TitleName, len(segments))
+	}
+	return segments
+}
+
+// encodeSegmented encodes the segments of an item that aren't done yet and
+// joins them into the destination
+func (w *Worker) encodeSegmented(ctx context.Context, item *QueueItem, segments []Segment, progressCh chan<- float64) error {
+	dir := partsDir(item)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create segment directory: %w", err)
+	}
+
+	var total, done float64
+	parts := make([]string, len(segments))
+	for i, segment := range segments {
+		parts[i] = filepath.Join(dir, fmt.Sprintf("%03d.mkv", i+1))
+		total += segment.Length
+
+		// A segment whose file is gone is encoded again
+		if _, err := os.Stat(parts[i]); err != nil && segments[i].Done {
+			segments[i].Done = false
+			w.queue.ResetSegment(item.ID, i)
+		}
+		if segments[i].Done {
+			done += segment.Length
+		}
+	}
+
+	for i, segment := range segments {
+		if segment.Done {
+			continue
+		}
+		if w.logCh != nil {
+			w.logCh <- fmt.Sprintf("Encoding segment %d/%d of %s", i+1, len(segments), item.TitleName)
+		}
+
+		// Report the progress of the whole item, not of the segment
+		// 100% means the item is done, so it's held back until the join
+		segmentCh := make(chan float64, 10)
+		forwarded := make(chan struct{})
+		go func() {
+			defer close(forwarded)
+			for progress := range segmentCh {
+				progressCh <- min((done+segment.Length*progress/100)/total*100, maxSegmentedProgress)
+			}
+		}()
+
+		err := w.handbrake.EncodeSegment(ctx, item, segment, parts[i], segmentCh, w.outputCh)
+		close(segmentCh)
+		<-forwarded
+		if err != nil {
+			return fmt.Errorf("segment %d/%d: %w", i+1, len(segments), err)
+		}
+
+		w.queue.CompleteSegment(item.ID, i)
+		done += segment.Length
+	}
+
+	if w.logCh != nil {
+		w.logCh <- fmt.Sprintf("Joining %d segments of %s", len(segments), item.TitleName)
+	}
+	joined := filepath.Join(dir, "joined.mkv")
+	if err := w.handbrake.Join(ctx, parts, joined); err != nil {
+		return err
+	}
+	if err := os.Rename(joined, item.DestPath); err != nil {
+		return fmt.Errorf("failed to move joined encode: %w", err)
+	}
+
+	os.RemoveAll(dir)
+
+	select {
+	case progressCh <- 100.0:
+	case <-ctx.Done():
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package encode
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration float64
+		chapters []float64
+		target   float64
+		want     []Segment
+	}{
+		{
+			name:     "too short to split",
+			duration: 5000,
+			target:   3600,
+		},
+		{
+			name:     "no target",
+			duration: 7200,
+			chapters: []float64{3600, 3600},
+		},
+		{
+			name:     "no chapters cuts by time",
+			duration: 7200,
+			target:   3600,
+			want:     []Segment{{Start: 0, Length: 3600}, {Start: 3600, Length: 3601}},
+		},
+		{
+			name:     "single chapter cuts by time",
+			duration: 7200,
+			chapters: []float64{7200},
+			target:   3600,
+			want:     []Segment{{Start: 0, Length: 3600}, {Start: 3600, Length: 3601}},
+		},
+		{
+			name:     "short time tail joins the segment before it",
+			duration: 8000,
+			target:   3600,
+			want:     []Segment{{Start: 0, Length: 3600}, {Start: 3600, Length: 4401}},
+		},
+		{
+			name:     "split exactly on a chapter boundary",
+			duration: 7200,
+			chapters: []float64{1800, 1800, 1800, 1800},
+			target:   3600,
+			want:     []Segment{{Chapters: "1-2", Length: 3600}, {Chapters: "3-4", Length: 3600}},
+		},
+		{
+			name:     "single chapter segments",
+			duration: 7200,
+			chapters: []float64{3600, 3600},
+			target:   3600,
+			want:     []Segment{{Chapters: "1", Length: 3600}, {Chapters: "2", Length: 3600}},
+		},
+		{
+			name:     "short chapter tail joins the segment before it",
+			duration: 7800,
+			chapters: []float64{3600, 3600, 600},
+			target:   3600,
+			want:     []Segment{{Chapters: "1", Length: 3600}, {Chapters: "2-3", Length: 4200}},
+		},
+		{
+			name:     "chapters add up to one segment",
+			duration: 5500,
+			chapters: []float64{3000, 2500},
+			target:   3600,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planSegments(tt.duration, tt.chapters, tt.target)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planSegments() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScan(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []string
+		wantDuration float64
+		wantChapters []float64
+	}{
+		{
+			name: "chapters",
+			lines: []string{
+				"+ title 1:",
+				"  + stream: /rips/Space Saga/title_t00.mkv",
+				"  + duration: 01:02:03",
+				"  + chapters:",
+				"    + 1: duration 00:30:00",
+				"    + 2: duration 00:32:03",
+			},
+			wantDuration: 3723,
+			wantChapters: []float64{1800, 1923},
+		},
+		{
+			name: "only the first title",
+			lines: []string{
+				"+ title 1:",
+				"  + duration: 00:10:00",
+				"  + chapters:",
+				"    + 1: duration 00:10:00",
+				"+ title 2:",
+				"  + duration: 00:20:00",
+				"  + chapters:",
+				"    + 1: duration 00:20:00",
+			},
+			wantDuration: 600,
+			wantChapters: []float64{600},
+		},
+		{
+			name: "no chapter lines",
+			lines: []string{
+				"+ title 1:",
+				"  + duration: 02:00:00",
+				"  + audio tracks:",
+				"    + 1, English (AC3) (5.1 ch)",
+			},
+			wantDuration: 7200,
+		},
+		{
+			name: "no titles",
+			lines: []string{
+				"No title found.",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duration, chapters := parseScan(tt.lines)
+			if duration != tt.wantDuration {
+				t.Errorf("duration = %v, want %v", duration, tt.wantDuration)
+			}
+			if !reflect.DeepEqual(chapters, tt.wantChapters) {
+				t.Errorf("chapters = %v, want %v", chapters, tt.wantChapters)
+			}
+		})
+	}
+}
+
+func TestSegmentArgs(t *testing.T) {
+	tests := []struct {
+		segment Segment
+		want    []string
+	}{
+		{Segment{Chapters: "4-6", Length: 3600}, []string{"--chapters", "4-6"}},
+		{Segment{Start: 0, Length: 3600}, []string{"--stop-at", "seconds:3600"}},
+		{Segment{Start: 3600, Length: 3601}, []string{"--start-at", "seconds:3600", "--stop-at", "seconds:3601"}},
+	}
+	for _, tt := range tests {
+		if got := tt.segment.args(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v.args() = %q, want %q", tt.segment, got, tt.want)
+		}
+	}
+}
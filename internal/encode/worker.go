@@ -2,8 +2,10 @@ package encode
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -14,8 +16,21 @@ const (
 	WorkerResume
 	WorkerStop
 	WorkerDelete
+
+	// Sent by the app's scheduling, not by users
+	WorkerHold           // Don't start new encodes
+	WorkerSuspend        // Don't start new encodes and suspend the running one
+	WorkerRelease        // Start encodes again and resume a suspended one
+	WorkerLowPriority    // Run encodes at the lowest CPU and I/O priority
+	WorkerNormalPriority // Run encodes at normal priority again
 )
 
+// ItemControl addresses a control command to the encode of one queue item
+type ItemControl struct {
+	ItemID  string // Empty addresses every running encode
+	Control WorkerControl
+}
+
 type ProgressUpdate struct {
 	ItemID   string
 	Progress float64
@@ -25,19 +40,28 @@ type Worker struct {
 	queue           *Queue
 	handbrake       *HandBrake
 	progressCh      chan<- ProgressUpdate
-	controlCh       <-chan WorkerControl
+	controlCh       <-chan ItemControl
 	logCh           chan<- string
+	outputCh        chan<- string // Raw HandBrake output
 	paused          bool
 	shouldDeleteCurrent bool
+
+	held         bool // Held by the app, e.g. outside the encode window
+	suspended    bool // Running encode was suspended by WorkerSuspend
+	encodePaused bool // Running encode was paused by the user
+
+	currentMu sync.Mutex
+	current   string // ID of the item being encoded
 }
 
-func NewWorker(queue *Queue, handbrake *HandBrake, progressCh chan<- ProgressUpdate, controlCh <-chan WorkerControl, logCh chan<- string) *Worker {
+func NewWorker(queue *Queue, handbrake *HandBrake, progressCh chan<- ProgressUpdate, controlCh <-chan ItemControl, logCh, outputCh chan<- string) *Worker {
 	return &Worker{
 		queue:      queue,
 		handbrake:  handbrake,
 		progressCh: progressCh,
 		controlCh:  controlCh,
 		logCh:      logCh,
+		outputCh:   outputCh,
 		paused:     false,
 	}
 }
@@ -56,12 +80,12 @@ func (w *Worker) Run(ctx context.Context) {
 			w.handleControl(ctrl)
 
 		case <-ticker.C:
-			if w.paused {
+			if w.paused || w.held {
 				continue
 			}
 
-			// Get next queued item
-			item := w.queue.GetNext()
+			// Take the next queued item
+			item := w.queue.Claim()
 			if item == nil {
 				continue
 			}
@@ -72,15 +96,59 @@ func (w *Worker) Run(ctx context.Context) {
 	}
 }
 
+// Current returns the ID of the item being encoded, or "" when idle
+func (w *Worker) Current() string {
+	w.currentMu.Lock()
+	defer w.currentMu.Unlock()
+	return w.current
+}
+
+func (w *Worker) setCurrent(id string) {
+	w.currentMu.Lock()
+	defer w.currentMu.Unlock()
+	w.current = id
+}
+
 // handleControl handles pause/resume/stop commands
-func (w *Worker) handleControl(ctrl WorkerControl) {
-	switch ctrl {
+// Pausing a single item only suspends its encode; pausing every encode
+// also keeps the worker from starting the next one
+func (w *Worker) handleControl(ctrl ItemControl) {
+	switch ctrl.Control {
 	case WorkerPause:
-		w.paused = true
+		if ctrl.ItemID == "" {
+			w.paused = true
+		}
+		w.encodePaused = true
 		w.handbrake.Pause()
 	case WorkerResume:
+		// Resuming by hand also overrides a suspension by the app
 		w.paused = false
+		w.encodePaused = false
+		w.suspended = false
 		w.handbrake.Resume()
+	case WorkerHold:
+		// Running encodes may continue, also one suspended before
+		w.held = true
+		if w.suspended {
+			w.suspended = false
+			w.handbrake.Resume()
+		}
+	case WorkerSuspend:
+		w.held = true
+		if w.Current() != "" && !w.encodePaused {
+			w.suspended = true
+			w.handbrake.Pause()
+		}
+	case WorkerRelease:
+		w.held = false
+		if w.suspended {
+			w.suspended = false
+			w.handbrake.Resume()
+		}
+	case WorkerLowPriority, WorkerNormalPriority:
+		if err := w.handbrake.SetLowPriority(ctrl.Control == WorkerLowPriority); err != nil && w.logCh != nil {
+			w.logCh <- fmt.Sprintf("Failed to change encoder priority: %v", err)
+		}
 	case WorkerStop:
 		w.shouldDeleteCurrent = false
 		w.handbrake.Cancel()
@@ -91,17 +159,21 @@ func (w *Worker) handleControl(ctrl WorkerControl) {
 }
 
 // encodeItem encodes a single item
+// The item was already marked as encoding by Queue.Claim
 func (w *Worker) encodeItem(ctx context.Context, item *QueueItem) {
-	// Mark as encoding
-	if err := w.queue.SetStatus(item.ID, StatusEncoding); err != nil {
-		fmt.Printf("Failed to set encoding status: %v\n", err)
-		return
-	}
+	w.setCurrent(item.ID)
+	w.handbrake.prepare()
+	defer func() {
+		w.setCurrent("")
+		w.encodePaused = false
+		w.suspended = false
+	}()
 
 	// Send initial progress update to set currentEncode in UI
+	// Resumed segmented items start at their done segments
 	w.progressCh <- ProgressUpdate{
 		ItemID:   item.ID,
-		Progress: 0,
+		Progress: item.Progress,
 	}
 
 	// Create progress channel for this item
@@ -121,7 +193,11 @@ func (w *Worker) encodeItem(ctx context.Context, item *QueueItem) {
 	// Monitor control channel during encoding
 	encodeDone := make(chan error, 1)
 	go func() {
-		encodeDone <- w.handbrake.Encode(ctx, item, progressCh, w.logCh)
+		if segments := w.segments(ctx, item); len(segments) > 0 {
+			encodeDone <- w.encodeSegmented(ctx, item, segments, progressCh)
+			return
+		}
+		encodeDone <- w.handbrake.Encode(ctx, item, progressCh, w.outputCh)
 	}()
 
 	// Wait for encoding to complete or control signal
@@ -139,9 +215,15 @@ handleResult:
 	close(progressCh)
 
 	if err != nil {
+		// Shutting down: the item stays encoding and is queued again on the
+		// next start, resuming after its done segments
+		if ctx.Err() != nil {
+			return
+		}
+
 		// Check if it was cancelled (process killed)
 		errStr := err.Error()
-		if strings.Contains(errStr, "killed") || strings.Contains(errStr, "signal") {
+		if errors.Is(err, errCanceled) || strings.Contains(errStr, "killed") || strings.Contains(errStr, "signal") {
 			// Check if we should delete or just fail
 			if w.shouldDeleteCurrent {
 				// Delete from queue
@@ -163,7 +245,9 @@ handleResult:
 
 		// Real failure - mark as failed
 		w.queue.Fail(item.ID, err)
-		fmt.Printf("Encoding failed for %s: %v\n", item.TitleName, err)
+		if w.logCh != nil {
+			w.logCh <- fmt.Sprintf("Encoding failed for %s: %v", item.TitleName, err)
+		}
 		return
 	}
 
@@ -0,0 +1,349 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Status is the outcome of a rip
+type Status string
+
+const (
+	StatusRipping   Status = "ripping"   // In progress, or interrupted and not resumed yet
+	StatusComplete  Status = "complete"  // Every selected title was ripped
+	StatusPartial   Status = "partial"   // Some titles failed to rip
+	StatusCancelled Status = "cancelled" // Cancelled before every title was ripped
+)
+
+// Encode results of a ripped title
+const (
+	EncodeComplete = "complete"
+	EncodeFailed   = "failed"
+)
+
+// Title is one title of a rip and what became of it
+type Title struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Seconds     int    `json:"seconds"`
+	Size        int64  `json:"size"` // Size reported by the disc scan
+	Ripped      bool   `json:"ripped"`
+	RipError    string `json:"rip_error,omitempty"`
+	RawPath     string `json:"raw_path,omitempty"`
+	RawSize     int64  `json:"raw_size,omitempty"`
+	EncodedPath string `json:"encoded_path,omitempty"`
+	EncodedSize int64  `json:"encoded_size,omitempty"`
+	Encode      string `json:"encode,omitempty"` // complete or failed, empty until the encode finished
+	EncodeError string `json:"encode_error,omitempty"`
+}
+
+// Record is the history of one rip of a disc
+// A disc ripped several times has one record per rip
+type Record struct {
+	ID          uint64     `json:"id"`
+	Fingerprint string     `json:"fingerprint"`
+	DiscName    string     `json:"disc_name"`
+	DiscType    string     `json:"disc_type"`
+	VolumeID    string     `json:"volume_id,omitempty"`
+	Drive       string     `json:"drive"`
+	Folder      string     `json:"folder"` // Output folder relative to output_dir
+	Status      Status     `json:"status"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Titles      []Title    `json:"titles"`
+}
+
+// Ripped returns how many titles were ripped
+func (r *Record) Ripped() int {
+	n := 0
+	for _, t := range r.Titles {
+		if t.Ripped {
+			n++
+		}
+	}
+	return n
+}
+
+// RawSize returns the total size of the ripped files
+func (r *Record) RawSize() int64 {
+	var size int64
+	for _, t := range r.Titles {
+		size += t.RawSize
+	}
+	return size
+}
+
+// EncodedSize returns the total size of the encoded files
+func (r *Record) EncodedSize() int64 {
+	var size int64
+	for _, t := range r.Titles {
+		size += t.EncodedSize
+	}
+	return size
+}
+
+// Title returns the title with the given ID, or nil
+func (r *Record) Title(id int) *Title {
+	for i := range r.Titles {
+		if r.Titles[i].ID == id {
+			return &r.Titles[i]
+		}
+	}
+	return nil
+}
+
+// matches reports whether the query appears in the disc name, folder,
+// fingerprint or any title name or path, ignoring case
+func (r *Record) matches(query string) bool {
+	if query == "" {
+		return true
+	}
+	fields := []string{r.DiscName, r.Folder, r.Fingerprint}
+	for _, t := range r.Titles {
+		fields = append(fields, t.Name, t.RawPath, t.EncodedPath)
+	}
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// EncodeResult is the outcome of encoding a ripped file
+type EncodeResult struct {
+	RawPath     string
+	EncodedPath string
+	EncodedSize int64
+	Failed      bool
+	Error       string
+}
+
+var ripsBucket = []byte("rips")
+
+// Store is the rip history database
+// The database is opened per operation so the CLI can read it while
+// mkvauto is running.
+type Store struct {
+	path string
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Add records a new rip and returns its ID
+func (s *Store) Add(rec *Record) (uint64, error) {
+	var id uint64
+	err := s.update(func(b *bolt.Bucket) error {
+		var err error
+		if id, err = b.NextSequence(); err != nil {
+			return err
+		}
+		rec.ID = id
+		return put(b, rec)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to add rip history: %w", err)
+	}
+	return id, nil
+}
+
+// Update changes a recorded rip
+func (s *Store) Update(id uint64, fn func(*Record)) error {
+	err := s.update(func(b *bolt.Bucket) error {
+		data := b.Get(key(id))
+		if data == nil {
+			return fmt.Errorf("no rip with ID %d", id)
+		}
+		var rec Record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return err
+		}
+		fn(&rec)
+		return put(b, &rec)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update rip history: %w", err)
+	}
+	return nil
+}
+
+// Get returns a recorded rip, or nil if there is none with that ID
+func (s *Store) Get(id uint64) (*Record, error) {
+	var rec *Record
+	err := s.view(func(b *bolt.Bucket) error {
+		data := b.Get(key(id))
+		if data == nil {
+			return nil
+		}
+		rec = &Record{}
+		return json.Unmarshal(data, rec)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rip history: %w", err)
+	}
+	return rec, nil
+}
+
+// ByFingerprint returns the earlier rips of a disc, newest first
+func (s *Store) ByFingerprint(fingerprint string) ([]*Record, error) {
+	return s.find(0, func(r *Record) bool { return r.Fingerprint == fingerprint })
+}
+
+// Search returns rips matching the query, newest first
+// An empty query returns everything; limit 0 means no limit.
+func (s *Store) Search(query string, limit int) ([]*Record, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	return s.find(limit, func(r *Record) bool { return r.matches(query) })
+}
+
+// SetEncodes records finished encodes on the titles they were ripped as
+// Results for files that weren't ripped by a recorded rip are ignored.
+func (s *Store) SetEncodes(results []EncodeResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	byRawPath := make(map[string]EncodeResult, len(results))
+	for _, r := range results {
+		byRawPath[r.RawPath] = r
+	}
+
+	err := s.update(func(b *bolt.Bucket) error {
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil && len(byRawPath) > 0; k, v = c.Prev() {
+			var rec Record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+
+			changed := false
+			for i := range rec.Titles {
+				t := &rec.Titles[i]
+				result, ok := byRawPath[t.RawPath]
+				if !ok || t.RawPath == "" {
+					continue
+				}
+				// The newest rip of a file owns it
+				delete(byRawPath, t.RawPath)
+
+				encode := EncodeComplete
+				if result.Failed {
+					encode = EncodeFailed
+				}
+				if t.Encode == encode && t.EncodedSize == result.EncodedSize && t.EncodeError == result.Error {
+					continue
+				}
+				t.Encode = encode
+				t.EncodeError = result.Error
+				t.EncodedSize = result.EncodedSize
+				if result.EncodedPath != "" {
+					t.EncodedPath = result.EncodedPath
+				}
+				changed = true
+			}
+			if changed {
+				if err := put(b, &rec); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record encodes: %w", err)
+	}
+	return nil
+}
+
+// find returns records accepted by match, newest first
+func (s *Store) find(limit int, match func(*Record) bool) ([]*Record, error) {
+	var records []*Record
+	err := s.view(func(b *bolt.Bucket) error {
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var rec Record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			if !match(&rec) {
+				continue
+			}
+			records = append(records, &rec)
+			if limit > 0 && len(records) == limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rip history: %w", err)
+	}
+	return records, nil
+}
+
+// view runs fn on the rips bucket in a read-only transaction
+// A missing database reads as empty.
+func (s *Store) view(fn func(*bolt.Bucket) error) error {
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return nil
+	}
+
+	db, err := bolt.Open(s.path, 0644, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(ripsBucket)
+		if b == nil {
+			return nil
+		}
+		return fn(b)
+	})
+}
+
+// update runs fn on the rips bucket in a read-write transaction
+func (s *Store) update(fn func(*bolt.Bucket) error) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	db, err := bolt.Open(s.path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(ripsBucket)
+		if err != nil {
+			return err
+		}
+		return fn(b)
+	})
+}
+
+func put(b *bolt.Bucket, rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return b.Put(key(rec.ID), data)
+}
+
+// key encodes an ID big-endian so records sort in insertion order
+func key(id uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, id)
+	return k
+}
@@ -4,25 +4,37 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 )
 
 type Client struct {
 	binaryPath string
+	discIndex  map[string]int       // Device path -> MakeMKV disc index
+	rips       map[string]*exec.Cmd // Device path -> running rip
+	paused     map[string]bool      // Device path -> rips are suspended
+	ripDirs    map[string]bool      // Private directories of running rips
+	mu         sync.Mutex
 }
 
 func NewClient(binaryPath string) *Client {
 	return &Client{
 		binaryPath: binaryPath,
+		discIndex:  make(map[string]int),
+		rips:       make(map[string]*exec.Cmd),
+		paused:     make(map[string]bool),
+		ripDirs:    make(map[string]bool),
 	}
 }
 
 // ScanDisc scans the disc and returns information about titles
 // statusCh receives status updates during the scan
 func (c *Client) ScanDisc(ctx context.Context, devicePath string, statusCh chan<- string) (*ScanResult, error) {
-	cmd := exec.CommandContext(ctx, c.binaryPath, "-r", "--progress=-stdout", "info", "disc:0")
+	cmd := exec.CommandContext(ctx, c.binaryPath, "-r", "--progress=-stdout", "info", c.source(devicePath))
 
 	// Get stdout pipe to read status messages
 	stdout, err := cmd.StdoutPipe()
@@ -72,11 +84,23 @@ func (c *Client) ScanDisc(ctx context.Context, devicePath string, statusCh chan<
 	return ParseInfo(fullOutput)
 }
 
-// RipTitle rips a single title to the output directory
+// RipTitle rips a single title to the output directory and returns the path of the ripped file
 // Sends progress updates to the progressCh channel
-func (c *Client) RipTitle(ctx context.Context, devicePath string, titleID int, outputDir string, progressCh chan<- float64, logCh chan<- string) error {
-	// makemkvcon -r --progress=-stdout mkv disc:0 titleID outputDir
-	cmd := exec.CommandContext(ctx, c.binaryPath, "-r", "--progress=-stdout", "mkv", "disc:0", fmt.Sprintf("%d", titleID), outputDir)
+//
+// makemkvcon writes into a private directory inside outputDir, so its file can't
+// be confused with earlier rips or other drives ripping into the same folder.
+// The file is moved into outputDir once it is verified, and never overwrites
+// an existing file. Directories left behind by a crash are removed before
+// the next rip into the same folder.
+func (c *Client) RipTitle(ctx context.Context, devicePath string, titleID int, outputDir string, progressCh chan<- float64, logCh chan<- string) (string, error) {
+	ripDir, err := c.newRipDir(outputDir, titleID)
+	if err != nil {
+		return "", err
+	}
+	defer c.removeRipDir(ripDir)
+
+	// makemkvcon -r --progress=-stdout mkv disc:N titleID outputDir
+	cmd := exec.CommandContext(ctx, c.binaryPath, "-r", "--progress=-stdout", "mkv", c.source(devicePath), fmt.Sprintf("%d", titleID), ripDir)
 
 	// Close stdin to prevent any prompts from blocking
 	cmd.Stdin = nil
@@ -84,20 +108,31 @@ func (c *Client) RipTitle(ctx context.Context, devicePath string, titleID int, o
 	// Get stdout pipe to read progress
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return fmt.Errorf("failed to get stdout pipe: %w", err)
+		return "", fmt.Errorf("failed to get stdout pipe: %w", err)
 	}
 
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		return fmt.Errorf("failed to get stderr pipe: %w", err)
+		return "", fmt.Errorf("failed to get stderr pipe: %w", err)
 	}
 
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start makemkvcon: %w", err)
+		return "", fmt.Errorf("failed to start makemkvcon: %w", err)
 	}
+	c.trackRip(devicePath, cmd)
+	defer c.untrackRip(devicePath)
+
+	// Readers must finish before Wait closes the pipes
+	var readers sync.WaitGroup
+	readers.Add(2)
+
+	// MakeMKV can exit successfully even though the title failed to save
+	var failure string
 
 	// Read progress from stdout
 	go func() {
+		defer readers.Done()
+
 		scanner := bufio.NewScanner(stdout)
 		// Increase buffer size for long lines
 		buf := make([]byte, 0, 64*1024)
@@ -106,6 +141,10 @@ func (c *Client) RipTitle(ctx context.Context, devicePath string, titleID int, o
 		for scanner.Scan() {
 			line := scanner.Text()
 
+			if code, message, ok := ParseMessage(line); ok && code == msgCopyFailed {
+				failure = message
+			}
+
 			// Send to log channel
 			if logCh != nil {
 				select {
@@ -142,6 +181,8 @@ func (c *Client) RipTitle(ctx context.Context, devicePath string, titleID int, o
 
 	// Also read stderr to avoid blocking
 	go func() {
+		defer readers.Done()
+
 		scanner := bufio.NewScanner(stderr)
 		// Increase buffer size for long lines
 		buf := make([]byte, 0, 64*1024)
@@ -161,8 +202,21 @@ func (c *Client) RipTitle(ctx context.Context, devicePath string, titleID int, o
 		}
 	}()
 
+	readers.Wait()
 	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("makemkvcon mkv failed: %w", err)
+		return "", fmt.Errorf("makemkvcon mkv failed: %w", err)
+	}
+	if failure != "" {
+		return "", fmt.Errorf("makemkvcon mkv failed: %s", failure)
+	}
+
+	ripped, err := rippedFile(ripDir)
+	if err != nil {
+		return "", err
+	}
+	outputPath, err := c.moveRipped(ripped, filepath.Join(outputDir, filepath.Base(ripped)))
+	if err != nil {
+		return "", err
 	}
 
 	// Send 100% when complete
@@ -172,9 +226,148 @@ func (c *Client) RipTitle(ctx context.Context, devicePath string, titleID int, o
 	default:
 	}
 
+	return outputPath, nil
+}
+
+// newRipDir creates the private directory a rip writes into, first removing
+// any left in outputDir by a rip that was killed before it could clean up
+func (c *Client) newRipDir(outputDir string, titleID int) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, _ := os.ReadDir(outputDir)
+	for _, e := range entries {
+		dir := filepath.Join(outputDir, e.Name())
+		if e.IsDir() && strings.HasPrefix(e.Name(), ".rip-t") && !c.ripDirs[dir] {
+			os.RemoveAll(dir)
+		}
+	}
+
+	dir, err := os.MkdirTemp(outputDir, fmt.Sprintf(".rip-t%02d-", titleID))
+	if err != nil {
+		return "", fmt.Errorf("failed to create rip directory: %w", err)
+	}
+	c.ripDirs[dir] = true
+	return dir, nil
+}
+
+// removeRipDir deletes the private directory of a finished rip
+func (c *Client) removeRipDir(dir string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.ripDirs, dir)
+	os.RemoveAll(dir)
+}
+
+// trackRip registers a running rip so it can be paused
+// A drive paused between titles starts its next title suspended
+func (c *Client) trackRip(devicePath string, cmd *exec.Cmd) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rips[devicePath] = cmd
+	if c.paused[devicePath] {
+		cmd.Process.Signal(syscall.SIGSTOP)
+	}
+}
+
+func (c *Client) untrackRip(devicePath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.rips, devicePath)
+}
+
+// Pause suspends the rip on a drive
+func (c *Client) Pause(devicePath string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.paused[devicePath] {
+		return nil
+	}
+	c.paused[devicePath] = true
+	if cmd := c.rips[devicePath]; cmd != nil {
+		return cmd.Process.Signal(syscall.SIGSTOP)
+	}
+	return nil
+}
+
+// Resume continues a paused rip on a drive
+func (c *Client) Resume(devicePath string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.paused[devicePath] {
+		return nil
+	}
+	delete(c.paused, devicePath)
+	if cmd := c.rips[devicePath]; cmd != nil {
+		return cmd.Process.Signal(syscall.SIGCONT)
+	}
 	return nil
 }
 
+// rippedFile returns the MKV file makemkvcon wrote into dir
+// A title rips to a single file; should there be more, the largest is the title
+func rippedFile(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read rip directory: %w", err)
+	}
+
+	var path string
+	var size int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".mkv") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if path == "" || info.Size() > size {
+			path = filepath.Join(dir, entry.Name())
+			size = info.Size()
+		}
+	}
+
+	if path == "" {
+		return "", fmt.Errorf("makemkvcon did not write an MKV file")
+	}
+	if size == 0 {
+		return "", fmt.Errorf("ripped file %s is empty", filepath.Base(path))
+	}
+	return path, nil
+}
+
+// moveRipped moves a ripped file to path, or to path with a numeric suffix
+// if it's taken. Rips move their files one at a time, so two drives ripping
+// into the same folder can't both pick the same free name.
+func (c *Client) moveRipped(ripped, path string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path = availablePath(path)
+	if err := os.Rename(ripped, path); err != nil {
+		return "", fmt.Errorf("failed to move ripped file: %w", err)
+	}
+	return path, nil
+}
+
+// availablePath returns path, or path with a numeric suffix if it already exists
+func availablePath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
 // CheckVersion checks if makemkvcon is available
 func (c *Client) CheckVersion() (string, error) {
 	cmd := exec.Command(c.binaryPath, "--version")
@@ -0,0 +1,73 @@
+package makemkv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewRipDirSweepsStaleDirs(t *testing.T) {
+	out := t.TempDir()
+	for _, name := range []string{".rip-t00-123", ".rip-t03-456", ".parts", "movie.mkv"} {
+		os.Mkdir(filepath.Join(out, name), 0755)
+	}
+
+	c := NewClient("makemkvcon")
+	running, err := c.newRipDir(out, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := c.newRipDir(out, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stale directories are gone, the running rip's and other files stay
+	for _, name := range []string{".rip-t00-123", ".rip-t03-456"} {
+		if _, err := os.Stat(filepath.Join(out, name)); !os.IsNotExist(err) {
+			t.Errorf("stale %s was not removed", name)
+		}
+	}
+	for _, path := range []string{running, next, filepath.Join(out, ".parts"), filepath.Join(out, "movie.mkv")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s was removed: %v", path, err)
+		}
+	}
+
+	c.removeRipDir(running)
+	if _, err := os.Stat(running); !os.IsNotExist(err) {
+		t.Error("finished rip directory was not removed")
+	}
+}
+
+func TestMoveRippedNeverOverwrites(t *testing.T) {
+	out := t.TempDir()
+	os.WriteFile(filepath.Join(out, "title_t00.mkv"), []byte("earlier"), 0644)
+
+	c := NewClient("makemkvcon")
+	const rips = 8
+	paths := make(chan string, rips)
+	for i := 0; i < rips; i++ {
+		src := filepath.Join(t.TempDir(), "title_t00.mkv")
+		os.WriteFile(src, []byte("rip"), 0644)
+		go func() {
+			path, err := c.moveRipped(src, filepath.Join(out, "title_t00.mkv"))
+			if err != nil {
+				t.Error(err)
+			}
+			paths <- path
+		}()
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < rips; i++ {
+		path := <-paths
+		if seen[path] {
+			t.Errorf("two rips moved to %s", path)
+		}
+		seen[path] = true
+	}
+	if data, _ := os.ReadFile(filepath.Join(out, "title_t00.mkv")); string(data) != "earlier" {
+		t.Error("existing file was overwritten")
+	}
+}
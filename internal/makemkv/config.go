@@ -0,0 +1,52 @@
+package makemkv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mmzim/mkvauto/internal/config"
+)
+
+// NewPolicy builds the title selection policy from the configured rules
+func NewPolicy(cfg config.SelectionConfig, thresholds config.Thresholds) (*Policy, error) {
+	policy := &Policy{
+		MovieThreshold:   time.Duration(thresholds.MovieMinMinutes) * time.Minute,
+		EpisodeThreshold: time.Duration(thresholds.EpisodeMinMinutes) * time.Minute,
+	}
+
+	for i, rc := range cfg.Rules {
+		action, err := ParseAction(rc.Action)
+		if err != nil {
+			return nil, fmt.Errorf("selection rule %d: %w", i+1, err)
+		}
+
+		rule := Rule{
+			Name:           rc.Name,
+			MinTitles:      rc.MinTitles,
+			MaxTitles:      rc.MaxTitles,
+			MinDuration:    time.Duration(rc.MinMinutes) * time.Minute,
+			MaxDuration:    time.Duration(rc.MaxMinutes) * time.Minute,
+			MinChapters:    rc.MinChapters,
+			AudioLanguages: rc.AudioLanguages,
+			Action:         action,
+		}
+		if rc.Label != "" {
+			if rule.Label, err = regexp.Compile(rc.Label); err != nil {
+				return nil, fmt.Errorf("selection rule %d: invalid label pattern: %w", i+1, err)
+			}
+		}
+		switch strings.ToLower(rc.DiscType) {
+		case "":
+		case "dvd":
+			rule.DiscType = "DVD"
+		default:
+			rule.DiscType = "Blu-ray"
+		}
+
+		policy.Rules = append(policy.Rules, rule)
+	}
+
+	return policy, nil
+}
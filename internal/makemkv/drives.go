@@ -0,0 +1,132 @@
+package makemkv
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Drive describes an optical drive as enumerated by MakeMKV
+type Drive struct {
+	Index    int    // MakeMKV disc index (used as disc:N)
+	Name     string // Drive model
+	DiscName string // Label of the inserted disc, if any
+	Device   string // Device path, e.g. /dev/sr0
+}
+
+// ListDrives asks MakeMKV for its drive list
+// Uses the special disc:9999 source, which only enumerates drives without scanning
+func (c *Client) ListDrives(ctx context.Context) ([]Drive, error) {
+	cmd := exec.CommandContext(ctx, c.binaryPath, "-r", "--cache=1", "info", "disc:9999")
+	output, err := cmd.Output()
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("makemkvcon drive list failed: %w", err)
+	}
+
+	return ParseDrives(string(output)), nil
+}
+
+// ResolveDrives maps each device path to its MakeMKV disc index
+// Devices that MakeMKV doesn't report are left unmapped and fall back to dev: sources
+func (c *Client) ResolveDrives(ctx context.Context, devices []string) (map[string]int, error) {
+	drives, err := c.ListDrives(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]int)
+	for _, device := range devices {
+		for _, d := range drives {
+			if d.Device == device {
+				resolved[device] = d.Index
+				c.SetDiscIndex(device, d.Index)
+				break
+			}
+		}
+	}
+
+	return resolved, nil
+}
+
+// SetDiscIndex records the MakeMKV disc index for a device path
+func (c *Client) SetDiscIndex(devicePath string, index int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.discIndex[devicePath] = index
+}
+
+// source returns the makemkvcon source argument for a device
+// Uses disc:N when the index is known, otherwise addresses the device directly
+func (c *Client) source(devicePath string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if index, ok := c.discIndex[devicePath]; ok {
+		return fmt.Sprintf("disc:%d", index)
+	}
+	return "dev:" + devicePath
+}
+
+// ParseDrives parses DRV lines from makemkvcon robot output
+// Format: DRV:index,visible,enabled,flags,"drive name","disc name","device path"
+func ParseDrives(output string) []Drive {
+	var drives []Drive
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "DRV:") {
+			continue
+		}
+
+		fields := splitFields(line[4:])
+		if len(fields) < 7 {
+			continue
+		}
+
+		index, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+
+		// Empty slots have no device path
+		if fields[6] == "" {
+			continue
+		}
+
+		drives = append(drives, Drive{
+			Index:    index,
+			Name:     fields[4],
+			DiscName: fields[5],
+			Device:   fields[6],
+		})
+	}
+
+	return drives
+}
+
+// splitFields splits a robot-mode record on commas outside of quotes
+// Quoted fields are returned without their quotes
+func splitFields(s string) []string {
+	var fields []string
+	var current strings.Builder
+	inQuotes := false
+
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '"':
+			inQuotes = !inQuotes
+		case ch == ',' && !inQuotes:
+			fields = append(fields, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(ch)
+		}
+	}
+	fields = append(fields, current.String())
+
+	return fields
+}
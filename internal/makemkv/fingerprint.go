@@ -0,0 +1,25 @@
+package makemkv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Fingerprint identifies a disc by its label, volume name and title layout
+// The same pressing gives the same fingerprint in any drive. The volume ID
+// isn't part of it, as it can't always be read; see SameVolume.
+func (r *ScanResult) Fingerprint() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", r.DiscName, r.VolumeName)
+	for _, t := range r.Titles {
+		fmt.Fprintf(h, "%d:%d:%d\n", t.ID, int64(t.Duration.Seconds()), t.Size)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// SameVolume reports whether two discs with the same fingerprint can be the
+// same disc: their volume IDs match, or one of them couldn't be read
+func SameVolume(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
@@ -0,0 +1,41 @@
+package makemkv
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	disc := func(volumeID string, size int64) *ScanResult {
+		return &ScanResult{DiscName: "Space Saga", VolumeName: "SPACE_SAGA", VolumeID: volumeID, Titles: []Title{
+			{ID: 0, Duration: 2 * time.Hour, Size: size},
+			{ID: 1, Duration: 3 * time.Minute, Size: 1 << 20},
+		}}
+	}
+
+	base := disc("", 37208051712).Fingerprint()
+	if got := disc("2f3c1a0e", 37208051712).Fingerprint(); got != base {
+		t.Errorf("reading the volume ID changed the fingerprint: %s != %s", got, base)
+	}
+	if got := disc("", 37208051713).Fingerprint(); got == base {
+		t.Error("a different title layout gave the same fingerprint")
+	}
+}
+
+func TestSameVolume(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"2f3c1a0e", "2f3c1a0e", true},
+		{"2f3c1a0e", "", true},
+		{"", "2f3c1a0e", true},
+		{"", "", true},
+		{"2f3c1a0e", "9b8d7e6f", false},
+	}
+	for _, tt := range tests {
+		if got := SameVolume(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameVolume(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -7,17 +7,23 @@ import (
 )
 
 type Title struct {
-	ID       int
-	Duration time.Duration
-	Name     string
-	Size     int64 // Size in bytes
-	Chapters int
+	ID         int
+	Duration   time.Duration
+	Name       string
+	Size       int64 // Size in bytes
+	Chapters   int
+	SourceFile string   // Source playlist or IFO, e.g. "00800.mpls"
+	Segments   []int    // Clip/cell numbers played in order (segment map)
+	OutputFile string   // File name MakeMKV will write, e.g. "title_t00.mkv"
+	Streams    []Stream // Video, audio and subtitle tracks in disc order
 }
 
 type ScanResult struct {
-	Titles   []Title
-	DiscName string
-	DiscType string // "DVD" or "Blu-ray"
+	Titles     []Title
+	DiscName   string
+	DiscType   string // "DVD" or "Blu-ray"
+	VolumeName string // Volume label of the disc
+	VolumeID   string // File system identity read from the device, see disk.VolumeID
 }
 
 // ParseInfo parses the output of 'makemkvcon info disc:0'
@@ -28,6 +34,7 @@ func ParseInfo(output string) (*ScanResult, error) {
 
 	lines := strings.Split(output, "\n")
 	titleMap := make(map[int]*Title)
+	guessedType := ""
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -35,29 +42,40 @@ func ParseInfo(output string) (*ScanResult, error) {
 			continue
 		}
 
-		// Parse disc info
-		// CINFO:2,0,"Disc Name"
-		if strings.HasPrefix(line, "CINFO:2,0,\"") {
-			result.DiscName = extractQuotedValue(line)
-		}
-
-		// Detect disc type
-		if strings.Contains(strings.ToLower(line), "blu-ray") || strings.Contains(strings.ToLower(line), "bd-rom") {
-			result.DiscType = "Blu-ray"
-		} else if strings.Contains(strings.ToLower(line), "dvd") {
-			if result.DiscType == "" {
-				result.DiscType = "DVD"
-			}
+		// Disc info
+		// CINFO:attributeID,code,"value"
+		if strings.HasPrefix(line, "CINFO:") {
+			parseDiscInfo(line, result)
+			continue
 		}
 
-		// Parse title info
-		// TINFO:titleID,attributeID,source,"value"
+		// Title info
+		// TINFO:titleID,attributeID,code,"value"
 		if strings.HasPrefix(line, "TINFO:") {
 			parseTitleInfo(line, titleMap)
+			continue
+		}
+
+		// Stream info
+		// SINFO:titleID,streamID,attributeID,code,"value"
+		if strings.HasPrefix(line, "SINFO:") {
+			parseStreamLine(line, titleMap)
+			continue
+		}
+
+		// Fallback when CINFO doesn't report the disc type
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "blu-ray") || strings.Contains(lower, "bd-rom") {
+			guessedType = "Blu-ray"
+		} else if strings.Contains(lower, "dvd") && guessedType == "" {
+			guessedType = "DVD"
 		}
 	}
 
 	// If disc type still not determined, default to DVD
+	if result.DiscType == "" {
+		result.DiscType = guessedType
+	}
 	if result.DiscType == "" {
 		result.DiscType = "DVD"
 	}
@@ -68,14 +86,43 @@ func ParseInfo(output string) (*ScanResult, error) {
 			result.Titles = append(result.Titles, *title)
 		}
 	}
+	SortByID(result.Titles)
 
 	return result, nil
 }
 
+// parseDiscInfo parses CINFO lines
+func parseDiscInfo(line string, result *ScanResult) {
+	parts := splitFields(line[6:]) // Skip "CINFO:"
+	if len(parts) < 3 {
+		return
+	}
+
+	attributeID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return
+	}
+	value := parts[2]
+
+	switch attributeID {
+	case attrType:
+		lower := strings.ToLower(value)
+		if strings.Contains(lower, "blu-ray") {
+			result.DiscType = "Blu-ray"
+		} else if strings.Contains(lower, "dvd") {
+			result.DiscType = "DVD"
+		}
+	case attrName:
+		result.DiscName = value
+	case attrVolumeName:
+		result.VolumeName = value
+	}
+}
+
 // parseTitleInfo parses TINFO lines
 func parseTitleInfo(line string, titleMap map[int]*Title) {
-	// Format: TINFO:titleID,attributeID,source,"value"
-	parts := strings.SplitN(line[6:], ",", 4) // Skip "TINFO:"
+	// Format: TINFO:titleID,attributeID,code,"value"
+	parts := splitFields(line[6:]) // Skip "TINFO:"
 	if len(parts) < 4 {
 		return
 	}
@@ -90,27 +137,62 @@ func parseTitleInfo(line string, titleMap map[int]*Title) {
 		return
 	}
 
-	value := extractQuotedValue(parts[3])
-
-	// Initialize title if not exists
-	if titleMap[titleID] == nil {
-		titleMap[titleID] = &Title{ID: titleID}
-	}
+	value := parts[3]
 
-	title := titleMap[titleID]
+	title := getTitle(titleMap, titleID)
 
 	switch attributeID {
-	case 2: // Title name
+	case attrName:
 		title.Name = value
-	case 9: // Duration
+	case attrDuration:
 		title.Duration = parseDuration(value)
-	case 10: // Size in bytes
+	case attrDiskSizeBytes:
 		size, _ := strconv.ParseInt(value, 10, 64)
 		title.Size = size
-	case 8: // Chapter count
+	case attrChapterCount:
 		chapters, _ := strconv.Atoi(value)
 		title.Chapters = chapters
+	case attrSourceFileName:
+		title.SourceFile = value
+	case attrSegmentsMap:
+		title.Segments = parseSegmentMap(value)
+	case attrOutputFileName:
+		title.OutputFile = value
+	}
+}
+
+// parseStreamLine parses SINFO lines
+func parseStreamLine(line string, titleMap map[int]*Title) {
+	// Format: SINFO:titleID,streamID,attributeID,code,"value"
+	parts := splitFields(line[6:]) // Skip "SINFO:"
+	if len(parts) < 5 {
+		return
+	}
+
+	titleID, err1 := strconv.Atoi(parts[0])
+	streamID, err2 := strconv.Atoi(parts[1])
+	attributeID, err3 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil || err3 != nil || streamID < 0 {
+		return
+	}
+	code, _ := strconv.Atoi(parts[3])
+
+	title := getTitle(titleMap, titleID)
+
+	// Streams are listed in order, but don't rely on it
+	for len(title.Streams) <= streamID {
+		title.Streams = append(title.Streams, Stream{Index: len(title.Streams)})
+	}
+
+	parseStreamInfo(&title.Streams[streamID], attributeID, code, parts[4])
+}
+
+// getTitle returns the title with the given ID, creating it if needed
+func getTitle(titleMap map[int]*Title, titleID int) *Title {
+	if titleMap[titleID] == nil {
+		titleMap[titleID] = &Title{ID: titleID}
 	}
+	return titleMap[titleID]
 }
 
 // extractQuotedValue extracts value from quoted string
@@ -171,6 +253,29 @@ func CalculatePercentage(current, total, max int) float64 {
 	return float64(current) / float64(max) * 100.0
 }
 
+// MSG code MakeMKV reports when a rip finished with failed titles
+// ("Copy complete. %1 titles saved, %2 failed.")
+const msgCopyFailed = 5037
+
+// ParseMessage parses MSG lines
+// Format: MSG:code,flags,count,"message","format","param",...
+func ParseMessage(line string) (code int, message string, ok bool) {
+	if !strings.HasPrefix(line, "MSG:") {
+		return 0, "", false
+	}
+
+	parts := splitFields(line[4:])
+	if len(parts) < 4 {
+		return 0, "", false
+	}
+	code, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", false
+	}
+
+	return code, parts[3], true
+}
+
 // ParseStatusMessage parses PRGC/PRGT status messages
 // Format: PRGC:code,id,"status message" or PRGT:code,id,"status message"
 func ParseStatusMessage(line string) (status string, ok bool) {
@@ -0,0 +1,146 @@
+package makemkv
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden is what a transcript is compared on
+type golden struct {
+	Fingerprint string
+	Result      *ScanResult
+}
+
+// TestParseInfoGolden parses makemkvcon -r info transcripts from testdata
+// and compares the result with the .golden file next to each one
+// Run with -update after an intended change to the parser
+func TestParseInfoGolden(t *testing.T) {
+	transcripts, err := filepath.Glob(filepath.Join("testdata", "*.txt"))
+	if err != nil || len(transcripts) == 0 {
+		t.Fatalf("no transcripts in testdata: %v", err)
+	}
+
+	for _, path := range transcripts {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := ParseInfo(string(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := json.MarshalIndent(golden{Fingerprint: result.Fingerprint(), Result: result}, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			goldenPath := strings.TrimSuffix(path, ".txt") + ".golden"
+			if *update {
+				if err := os.WriteFile(goldenPath, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("parsed %s differs from %s:\n%s", path, goldenPath, got)
+			}
+		})
+	}
+}
+
+func TestParseInfoDetails(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "bluray_decoys.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, _ := ParseInfo(string(data))
+
+	if result.DiscType != "Blu-ray" || result.DiscName != "Space Saga" || result.VolumeName != "SPACE_SAGA" {
+		t.Errorf("disc = %q %q %q", result.DiscType, result.DiscName, result.VolumeName)
+	}
+	if len(result.Titles) != 5 {
+		t.Fatalf("got %d titles, want 5", len(result.Titles))
+	}
+
+	main := result.Titles[0]
+	if main.Duration != 2*time.Hour+4*time.Minute+52*time.Second || main.Chapters != 24 || main.Size != 37208051712 {
+		t.Errorf("main title = %s, %d chapters, %d bytes", main.Duration, main.Chapters, main.Size)
+	}
+	if main.Resolution() != "1920x1080" {
+		t.Errorf("resolution = %q", main.Resolution())
+	}
+	if langs := main.AudioLanguages(); strings.Join(langs, ",") != "eng,fra" {
+		t.Errorf("audio languages = %v", langs)
+	}
+
+	audio := main.AudioStreams()
+	if len(audio) != 4 || !audio[1].CoreAudio || !audio[2].Commentary || audio[0].Channels != 8 {
+		t.Errorf("audio flags = %+v", audio)
+	}
+	subs := main.SubtitleStreams()
+	if len(subs) != 2 || subs[0].Forced || !subs[1].Forced {
+		t.Errorf("subtitle flags = %+v", subs)
+	}
+
+	if got := result.Titles[1].Segments; len(got) != 30 || got[0] != 2 || got[1] != 1 {
+		t.Errorf("segment map of title 1 = %v", got)
+	}
+}
+
+func TestParseInfoDiscType(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"from CINFO", `CINFO:1,6206,"Blu-ray disc"`, "Blu-ray"},
+		{"DVD from CINFO", `CINFO:1,6209,"DVD disc"`, "DVD"},
+		{"guessed from drive", `DRV:0,2,999,12,"BD-ROM PIONEER","X","/dev/sr0"`, "Blu-ray"},
+		{"Blu-ray wins over DVD", "MSG:1,0,0,\"DVD+-RW drive\"\nMSG:2,0,0,\"Blu-ray disc found\"", "Blu-ray"},
+		{"defaults to DVD", `TCOUNT:0`, "DVD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := ParseInfo(tt.output)
+			if result.DiscType != tt.want {
+				t.Errorf("DiscType = %q, want %q", result.DiscType, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProgressAndMessages(t *testing.T) {
+	if cur, total, max, ok := ParseProgress("PRGV:16384,32768,65536"); !ok || cur != 16384 || total != 32768 || max != 65536 {
+		t.Errorf("ParseProgress = %d %d %d %v", cur, total, max, ok)
+	}
+	if _, _, _, ok := ParseProgress("PRGV:1,2"); ok {
+		t.Error("ParseProgress accepted a short line")
+	}
+	if pct := CalculatePercentage(16384, 0, 65536); pct != 25 {
+		t.Errorf("CalculatePercentage = %v", pct)
+	}
+
+	code, msg, ok := ParseMessage(`MSG:5037,516,2,"Copy complete. 1 titles saved, 1 failed.","Copy complete. %1 titles saved, %2 failed.","1","1"`)
+	if !ok || code != msgCopyFailed || msg != "Copy complete. 1 titles saved, 1 failed." {
+		t.Errorf("ParseMessage = %d %q %v", code, msg, ok)
+	}
+
+	if status, ok := ParseStatusMessage(`PRGC:5017,0,"Saving to MKV file"`); !ok || status != "Saving to MKV file" {
+		t.Errorf("ParseStatusMessage = %q %v", status, ok)
+	}
+}
@@ -0,0 +1,156 @@
+package makemkv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Action is what a selection rule does with the titles it matches
+type Action string
+
+const (
+	ActionLongest  Action = "longest"  // Only the longest matching title
+	ActionEpisodes Action = "episodes" // Matching titles of episode length, in disc order
+	ActionAll      Action = "all"      // Every matching title, extras included
+	ActionAsk      Action = "ask"      // Always let the user pick
+)
+
+// ParseAction validates a configured action name
+func ParseAction(s string) (Action, error) {
+	switch a := Action(strings.ToLower(s)); a {
+	case ActionLongest, ActionEpisodes, ActionAll, ActionAsk:
+		return a, nil
+	default:
+		return "", fmt.Errorf("invalid action: %s (use longest, episodes, all or ask)", s)
+	}
+}
+
+// Rule selects titles for discs matching all of its conditions
+// Zero-valued conditions match anything. Duration, chapter and language
+// conditions filter titles; the title count applies to the titles left over.
+type Rule struct {
+	Name           string
+	Label          *regexp.Regexp // Matched against the disc label
+	DiscType       string         // "DVD" or "Blu-ray"
+	MinTitles      int
+	MaxTitles      int
+	MinDuration    time.Duration
+	MaxDuration    time.Duration
+	MinChapters    int
+	AudioLanguages []string // Title needs at least one of these audio tracks
+	Action         Action
+}
+
+// Policy is an ordered list of rules; the first matching rule wins
+type Policy struct {
+	Rules            []Rule
+	MovieThreshold   time.Duration
+	EpisodeThreshold time.Duration
+}
+
+// Selection is the outcome of a matching rule
+type Selection struct {
+	Rule   string
+	Action Action
+	Titles []Title // Empty for ActionAsk
+}
+
+// Select applies the first rule matching the disc
+// Returns false if no rule matches, leaving the default heuristic to the caller.
+func (p *Policy) Select(label, discType string, titles []Title) (Selection, bool) {
+	if p == nil {
+		return Selection{}, false
+	}
+
+	for i, rule := range p.Rules {
+		matched, ok := rule.match(label, discType, titles)
+		if !ok {
+			continue
+		}
+
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+		sel := Selection{Rule: name, Action: rule.Action}
+
+		switch rule.Action {
+		case ActionLongest:
+			// Keeps the first of equally long titles, so ranking order breaks ties
+			longest := matched[0]
+			for _, t := range matched[1:] {
+				if t.Duration > longest.Duration {
+					longest = t
+				}
+			}
+			sel.Titles = []Title{longest}
+		case ActionEpisodes:
+			if rule.MinDuration == 0 && rule.MaxDuration == 0 {
+				// No explicit range, so skip "play all" titles and extras
+				sel.Titles = SelectEpisodes(matched, p.MovieThreshold, p.EpisodeThreshold)
+			} else {
+				sel.Titles = matched
+				SortByID(sel.Titles)
+			}
+		case ActionAll:
+			sel.Titles = matched
+			SortByID(sel.Titles)
+		}
+
+		return sel, true
+	}
+
+	return Selection{}, false
+}
+
+// match checks the rule's conditions and returns the titles passing its filters
+func (r Rule) match(label, discType string, titles []Title) ([]Title, bool) {
+	if r.Label != nil && !r.Label.MatchString(label) {
+		return nil, false
+	}
+	if r.DiscType != "" && !strings.EqualFold(r.DiscType, discType) {
+		return nil, false
+	}
+
+	var matched []Title
+	for _, t := range titles {
+		if r.matchTitle(t) {
+			matched = append(matched, t)
+		}
+	}
+
+	if len(matched) == 0 || len(matched) < r.MinTitles {
+		return nil, false
+	}
+	if r.MaxTitles > 0 && len(matched) > r.MaxTitles {
+		return nil, false
+	}
+
+	return matched, true
+}
+
+// matchTitle checks the per-title conditions
+func (r Rule) matchTitle(t Title) bool {
+	if t.Duration < r.MinDuration {
+		return false
+	}
+	if r.MaxDuration > 0 && t.Duration > r.MaxDuration {
+		return false
+	}
+	if t.Chapters < r.MinChapters {
+		return false
+	}
+	if len(r.AudioLanguages) == 0 {
+		return true
+	}
+	for _, lang := range t.AudioLanguages() {
+		for _, want := range r.AudioLanguages {
+			if strings.EqualFold(lang, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
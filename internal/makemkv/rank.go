@@ -0,0 +1,237 @@
+package makemkv
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Titles whose durations differ by no more than this can be the same content
+	durationTolerance = 2 * time.Second
+
+	// Titles without a segment map are duplicates if their sizes are this close
+	sizeTolerance = 0.01
+
+	// Fraction of segments two playlists must share to be variants of each other
+	segmentOverlap = 0.5
+
+	// This many same-length variants of one title means the disc is obfuscated
+	decoyThreshold = 3
+)
+
+// Ranking is the result of deduplicating a disc's titles
+type Ranking struct {
+	Titles     []Title     // One title per distinct piece of content, best first
+	Dropped    map[int]int // Dropped title ID -> ID of the title kept instead
+	Obfuscated bool        // The disc carries decoy playlists
+	Reasons    []string    // Human-readable explanation of each decision
+}
+
+// RankTitles groups titles that play the same content and keeps the best of each
+//
+// Titles are the same content when their durations match and they share most of
+// their segments (or, without a segment map, their sizes match). Identical
+// segment maps are plain duplicates such as angles; differing ones are alternate
+// or decoy playlists, which obfuscated Blu-rays ship by the dozen. Within a group
+// the main feature is the one with the most chapters and the most sequential
+// segment order. The result is the same regardless of input order.
+func RankTitles(titles []Title) Ranking {
+	ranking := Ranking{Dropped: make(map[int]int)}
+	if len(titles) == 0 {
+		return ranking
+	}
+
+	sorted := make([]Title, len(titles))
+	copy(sorted, titles)
+	SortByID(sorted)
+
+	// Union titles that play the same content
+	parent := make([]int, len(sorted))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range sorted {
+		for j := i + 1; j < len(sorted); j++ {
+			if sameContent(sorted[i], sorted[j]) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := make(map[int][]Title)
+	var roots []int
+	for i, t := range sorted {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], t)
+	}
+
+	for _, root := range roots {
+		group := groups[root]
+		sort.SliceStable(group, func(i, j int) bool {
+			return betterTitle(group[i], group[j])
+		})
+		best := group[0]
+		ranking.Titles = append(ranking.Titles, best)
+		if len(group) == 1 {
+			continue
+		}
+
+		var droppedIDs []int
+		identical := true
+		for _, t := range group[1:] {
+			ranking.Dropped[t.ID] = best.ID
+			droppedIDs = append(droppedIDs, t.ID)
+			if !sameSegments(t, best) {
+				identical = false
+			}
+		}
+		sort.Ints(droppedIDs)
+		dropped := make([]string, len(droppedIDs))
+		for i, id := range droppedIDs {
+			dropped[i] = strconv.Itoa(id)
+		}
+
+		switch {
+		case identical:
+			ranking.Reasons = append(ranking.Reasons, fmt.Sprintf("Titles %s duplicate %s",
+				strings.Join(dropped, ", "), describeTitle(best)))
+		case len(group) >= decoyThreshold:
+			ranking.Obfuscated = true
+			ranking.Reasons = append(ranking.Reasons, fmt.Sprintf("%d playlists of %s look like obfuscation decoys, kept %s and skipped titles %s",
+				len(group), formatLength(best.Duration), describeTitle(best), strings.Join(dropped, ", ")))
+		default:
+			ranking.Reasons = append(ranking.Reasons, fmt.Sprintf("Titles %s are alternate playlists of %s",
+				strings.Join(dropped, ", "), describeTitle(best)))
+		}
+	}
+
+	// Longest content first, then by the same preference used within groups
+	sort.SliceStable(ranking.Titles, func(i, j int) bool {
+		a, b := ranking.Titles[i], ranking.Titles[j]
+		if diff := a.Duration - b.Duration; diff > durationTolerance || diff < -durationTolerance {
+			return diff > 0
+		}
+		return betterTitle(a, b)
+	})
+
+	return ranking
+}
+
+// sameContent reports whether two titles are copies or variants of each other
+func sameContent(a, b Title) bool {
+	diff := a.Duration - b.Duration
+	if diff > durationTolerance || diff < -durationTolerance {
+		return false
+	}
+
+	if len(a.Segments) > 0 && len(b.Segments) > 0 {
+		return sharedSegments(a.Segments, b.Segments) >= segmentOverlap
+	}
+
+	if a.Size == 0 || b.Size == 0 || a.Chapters != b.Chapters {
+		return false
+	}
+	larger, smaller := a.Size, b.Size
+	if smaller > larger {
+		larger, smaller = smaller, larger
+	}
+	return float64(larger-smaller) <= float64(larger)*sizeTolerance
+}
+
+// sharedSegments returns the fraction of the larger segment set found in both
+func sharedSegments(a, b []int) float64 {
+	set := make(map[int]bool, len(a))
+	for _, s := range a {
+		set[s] = true
+	}
+	shared := 0
+	seen := make(map[int]bool, len(b))
+	for _, s := range b {
+		if set[s] && !seen[s] {
+			shared++
+		}
+		seen[s] = true
+	}
+
+	total := len(set)
+	if len(seen) > total {
+		total = len(seen)
+	}
+	return float64(shared) / float64(total)
+}
+
+// sameSegments reports whether two titles play identical segment maps
+func sameSegments(a, b Title) bool {
+	if len(a.Segments) != len(b.Segments) {
+		return false
+	}
+	for i := range a.Segments {
+		if a.Segments[i] != b.Segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// segmentOrder returns the fraction of segments that follow their predecessor
+// on disc; the real feature plays its clips in order, decoys shuffle them
+func segmentOrder(segments []int) float64 {
+	if len(segments) < 2 {
+		return 1
+	}
+	ordered := 0
+	for i := 1; i < len(segments); i++ {
+		if segments[i] > segments[i-1] {
+			ordered++
+		}
+	}
+	return float64(ordered) / float64(len(segments)-1)
+}
+
+// betterTitle reports whether a is more likely the main feature than b
+// Prefers more chapters, then sequential segments, then size, then lower ID
+func betterTitle(a, b Title) bool {
+	if a.Chapters != b.Chapters {
+		return a.Chapters > b.Chapters
+	}
+	if oa, ob := segmentOrder(a.Segments), segmentOrder(b.Segments); oa != ob {
+		return oa > ob
+	}
+	if a.Size != b.Size {
+		return a.Size > b.Size
+	}
+	return a.ID < b.ID
+}
+
+// describeTitle names a title for the log, e.g. "title 3 (00800.mpls, 28 chapters)"
+func describeTitle(t Title) string {
+	var details []string
+	if t.SourceFile != "" {
+		details = append(details, t.SourceFile)
+	}
+	if t.Chapters > 0 {
+		details = append(details, fmt.Sprintf("%d chapters", t.Chapters))
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("title %d", t.ID)
+	}
+	return fmt.Sprintf("title %d (%s)", t.ID, strings.Join(details, ", "))
+}
+
+// formatLength formats a duration as h:mm:ss
+func formatLength(d time.Duration) string {
+	return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
@@ -0,0 +1,214 @@
+package makemkv
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clips returns the segment numbers from..to in order
+func clips(from, to int) []int {
+	var s []int
+	for i := from; i <= to; i++ {
+		s = append(s, i)
+	}
+	return s
+}
+
+// join concatenates segment runs into one playlist
+func join(runs ...[]int) []int {
+	var s []int
+	for _, r := range runs {
+		s = append(s, r...)
+	}
+	return s
+}
+
+const feature = 2*time.Hour + 4*time.Minute + 52*time.Second
+
+func TestRankTitles(t *testing.T) {
+	tests := []struct {
+		name       string
+		disc       ScanResult
+		wantIDs    []int       // Kept titles in ranking order
+		wantDrop   map[int]int // Dropped ID -> kept ID
+		obfuscated bool
+		reason     string // Substring of the only reason, if any
+	}{
+		{
+			name:     "empty disc",
+			disc:     ScanResult{},
+			wantDrop: map[int]int{},
+		},
+		{
+			name: "distinct titles longest first",
+			disc: ScanResult{Titles: []Title{
+				{ID: 0, Duration: 22 * time.Minute, Segments: clips(1, 5)},
+				{ID: 1, Duration: feature, Segments: clips(6, 30)},
+				{ID: 2, Duration: 3 * time.Minute, Segments: []int{31}},
+			}},
+			wantIDs:  []int{1, 0, 2},
+			wantDrop: map[int]int{},
+		},
+		{
+			name: "obfuscated disc keeps the sequential playlist",
+			disc: ScanResult{Titles: []Title{
+				{ID: 0, Duration: feature, Chapters: 24, Size: 37208051712, Segments: join(clips(2, 2), clips(1, 1), clips(3, 30))},
+				{ID: 1, Duration: feature, Chapters: 24, Size: 37208051712, Segments: join(clips(1, 10), clips(21, 30), clips(11, 20))},
+				{ID: 2, Duration: feature - time.Second, Chapters: 24, Size: 37207984128, Segments: clips(1, 30)},
+				{ID: 3, Duration: feature, Chapters: 24, Size: 37208051712, Segments: join(clips(1, 5), clips(25, 30), clips(6, 24))},
+				{ID: 4, Duration: 2*time.Minute + 31*time.Second, Segments: []int{40}},
+			}},
+			wantIDs:    []int{2, 4},
+			wantDrop:   map[int]int{0: 2, 1: 2, 3: 2},
+			obfuscated: true,
+			reason:     "4 playlists of 2:04:51 look like obfuscation decoys, kept title 2",
+		},
+		{
+			name: "more chapters beats segment order",
+			disc: ScanResult{Titles: []Title{
+				{ID: 0, Duration: feature, Chapters: 12, Segments: clips(1, 30)},
+				{ID: 1, Duration: feature, Chapters: 24, Segments: join(clips(2, 2), clips(1, 1), clips(3, 30))},
+				{ID: 2, Duration: feature, Chapters: 12, Segments: join(clips(1, 10), clips(21, 30), clips(11, 20))},
+			}},
+			wantIDs:    []int{1},
+			wantDrop:   map[int]int{0: 1, 2: 1},
+			obfuscated: true,
+		},
+		{
+			name: "two variants are alternate playlists, not decoys",
+			disc: ScanResult{Titles: []Title{
+				{ID: 0, Duration: feature, Chapters: 24, Segments: clips(1, 30)},
+				{ID: 1, Duration: feature, Chapters: 24, Segments: join(clips(1, 29), []int{31})},
+			}},
+			wantIDs:  []int{0},
+			wantDrop: map[int]int{1: 0},
+			reason:   "Titles 1 are alternate playlists of title 0 (24 chapters)",
+		},
+		{
+			name: "identical segment maps are duplicates",
+			disc: ScanResult{Titles: []Title{
+				{ID: 0, Duration: feature, Chapters: 24, SourceFile: "00800.mpls", Segments: clips(1, 30)},
+				{ID: 1, Duration: feature, Chapters: 24, SourceFile: "00801.mpls", Segments: clips(1, 30)},
+				{ID: 2, Duration: feature, Chapters: 24, SourceFile: "00802.mpls", Segments: clips(1, 30)},
+			}},
+			wantIDs:  []int{0},
+			wantDrop: map[int]int{1: 0, 2: 0},
+			reason:   "Titles 1, 2 duplicate title 0 (00800.mpls, 24 chapters)",
+		},
+		{
+			name: "same length with little overlap is different content",
+			disc: ScanResult{Titles: []Title{
+				{ID: 0, Duration: 44 * time.Minute, Segments: clips(1, 10)},
+				{ID: 1, Duration: 44*time.Minute + time.Second, Segments: join(clips(1, 4), clips(11, 16))},
+			}},
+			wantIDs:  []int{0, 1},
+			wantDrop: map[int]int{},
+		},
+		{
+			name: "duration outside tolerance is different content",
+			disc: ScanResult{Titles: []Title{
+				{ID: 0, Duration: feature, Segments: clips(1, 30)},
+				{ID: 1, Duration: feature + 3*time.Second, Segments: clips(1, 30)},
+			}},
+			wantIDs:  []int{1, 0},
+			wantDrop: map[int]int{},
+		},
+		{
+			name: "DVD titles without segment maps match on size",
+			disc: ScanResult{Titles: []Title{
+				{ID: 0, Duration: 22 * time.Minute, Chapters: 6, Size: 1000000000},
+				{ID: 1, Duration: 22 * time.Minute, Chapters: 6, Size: 995000000},
+				{ID: 2, Duration: 22 * time.Minute, Chapters: 6, Size: 900000000},
+				{ID: 3, Duration: 22 * time.Minute, Chapters: 5, Size: 1000000000},
+			}},
+			wantIDs:  []int{0, 2, 3},
+			wantDrop: map[int]int{1: 0},
+			reason:   "Titles 1 duplicate title 0 (6 chapters)",
+		},
+		{
+			name: "unknown sizes are never merged",
+			disc: ScanResult{Titles: []Title{
+				{ID: 0, Duration: 22 * time.Minute, Chapters: 6},
+				{ID: 1, Duration: 22 * time.Minute, Chapters: 6},
+			}},
+			wantIDs:  []int{0, 1},
+			wantDrop: map[int]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranking := RankTitles(tt.disc.Titles)
+
+			var ids []int
+			for _, title := range ranking.Titles {
+				ids = append(ids, title.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("kept %v, want %v", ids, tt.wantIDs)
+			}
+			if !reflect.DeepEqual(ranking.Dropped, tt.wantDrop) {
+				t.Errorf("dropped %v, want %v", ranking.Dropped, tt.wantDrop)
+			}
+			if ranking.Obfuscated != tt.obfuscated {
+				t.Errorf("obfuscated = %v, want %v", ranking.Obfuscated, tt.obfuscated)
+			}
+			if tt.reason != "" && (len(ranking.Reasons) != 1 || !strings.Contains(ranking.Reasons[0], tt.reason)) {
+				t.Errorf("reasons = %q, want one containing %q", ranking.Reasons, tt.reason)
+			}
+		})
+	}
+}
+
+// TestRankTitlesOrderIndependent checks every rotation and the reversal of the
+// input gives the same ranking
+func TestRankTitlesOrderIndependent(t *testing.T) {
+	titles := []Title{
+		{ID: 0, Duration: feature, Chapters: 24, Segments: join(clips(2, 2), clips(1, 1), clips(3, 30))},
+		{ID: 1, Duration: feature, Chapters: 24, Segments: clips(1, 30)},
+		{ID: 2, Duration: feature, Chapters: 24, Segments: join(clips(1, 10), clips(21, 30), clips(11, 20))},
+		{ID: 3, Duration: 22 * time.Minute, Chapters: 6, Size: 1000000000},
+		{ID: 4, Duration: 22 * time.Minute, Chapters: 6, Size: 999000000},
+	}
+	want := RankTitles(titles)
+
+	inputs := [][]Title{}
+	for i := range titles {
+		inputs = append(inputs, append(append([]Title{}, titles[i:]...), titles[:i]...))
+	}
+	reversed := append([]Title{}, titles...)
+	sort.Slice(reversed, func(i, j int) bool { return reversed[i].ID > reversed[j].ID })
+	inputs = append(inputs, reversed)
+
+	for _, input := range inputs {
+		if got := RankTitles(input); !reflect.DeepEqual(got, want) {
+			t.Errorf("ranking of %v differs:\n got %+v\nwant %+v", idsOf(input), got, want)
+		}
+	}
+}
+
+func TestSelectTitlesPicksMainFeature(t *testing.T) {
+	disc := ScanResult{DiscType: "Blu-ray", Titles: []Title{
+		{ID: 0, Duration: 2*time.Minute + 31*time.Second, Segments: []int{40}},
+		{ID: 1, Duration: feature, Chapters: 24, Segments: join(clips(1, 5), clips(25, 30), clips(6, 24))},
+		{ID: 2, Duration: feature, Chapters: 24, Segments: join(clips(2, 2), clips(1, 1), clips(3, 30))},
+		{ID: 3, Duration: feature, Chapters: 24, Segments: clips(1, 30)},
+		{ID: 4, Duration: 30 * time.Minute, Segments: []int{41, 42}},
+	}}
+
+	selected := SelectTitles(disc.Titles, 60*time.Minute, 18*time.Minute)
+	if len(selected) != 1 || selected[0].ID != 3 {
+		t.Errorf("selected %v, want [3]", idsOf(selected))
+	}
+}
+
+func idsOf(titles []Title) []int {
+	ids := make([]int, len(titles))
+	for i, t := range titles {
+		ids[i] = t.ID
+	}
+	return ids
+}
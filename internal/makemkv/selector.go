@@ -1,16 +1,19 @@
 package makemkv
 
 import (
+	"sort"
 	"time"
 )
 
 // SelectTitles implements the intelligent title selection logic:
 // - If ANY title >= 60 minutes: Rip ONLY the longest title (movie mode)
 // - If ALL titles < 60 minutes: Rip ALL titles >= 18 minutes (TV episode mode)
+// Duplicate and decoy playlists are dropped first (see RankTitles)
 func SelectTitles(titles []Title, movieThreshold, episodeThreshold time.Duration) []Title {
 	if len(titles) == 0 {
 		return nil
 	}
+	titles = RankTitles(titles).Titles
 
 	// Find the maximum duration
 	maxDuration := time.Duration(0)
@@ -22,8 +25,8 @@ func SelectTitles(titles []Title, movieThreshold, episodeThreshold time.Duration
 
 	// Movie mode: at least one title >= movie threshold (default 60 min)
 	if maxDuration >= movieThreshold {
-		// Return only the longest title
-		return []Title{findLongestTitle(titles)}
+		// Return only the longest title; ranking puts it first
+		return []Title{titles[0]}
 	}
 
 	// TV mode: all titles < movie threshold
@@ -35,21 +38,36 @@ func SelectTitles(titles []Title, movieThreshold, episodeThreshold time.Duration
 		}
 	}
 
+	SortByID(selected)
 	return selected
 }
 
-// findLongestTitle returns the title with the longest duration
-func findLongestTitle(titles []Title) Title {
-	if len(titles) == 0 {
-		return Title{}
+// SelectEpisodes picks the episodes of a TV disc for a series session
+// Titles >= the episode threshold but shorter than a movie are episodes; longer
+// ones are usually "play all" titles. Falls back to every title >= the episode
+// threshold when that leaves nothing. Results are in title ID order.
+func SelectEpisodes(titles []Title, movieThreshold, episodeThreshold time.Duration) []Title {
+	var episodes, long []Title
+	for _, title := range titles {
+		switch {
+		case title.Duration >= movieThreshold:
+			long = append(long, title)
+		case title.Duration >= episodeThreshold:
+			episodes = append(episodes, title)
+		}
 	}
 
-	longest := titles[0]
-	for _, title := range titles[1:] {
-		if title.Duration > longest.Duration {
-			longest = title
-		}
+	if len(episodes) == 0 {
+		episodes = long
 	}
 
-	return longest
+	SortByID(episodes)
+	return episodes
+}
+
+// SortByID orders titles by their MakeMKV title ID (disc playback order)
+func SortByID(titles []Title) {
+	sort.SliceStable(titles, func(i, j int) bool {
+		return titles[i].ID < titles[j].ID
+	})
 }
@@ -0,0 +1,211 @@
+package makemkv
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MakeMKV robot-mode attribute IDs (apdefs.h)
+const (
+	attrType               = 1
+	attrName               = 2
+	attrLangCode           = 3
+	attrLangName           = 4
+	attrCodecID            = 5
+	attrCodecShort         = 6
+	attrCodecLong          = 7
+	attrChapterCount       = 8
+	attrDuration           = 9
+	attrDiskSizeBytes      = 11
+	attrBitrate            = 13
+	attrAudioChannelsCount = 14
+	attrSourceFileName     = 16
+	attrAudioSampleRate    = 17
+	attrVideoSize          = 19
+	attrVideoAspectRatio   = 20
+	attrVideoFrameRate     = 21
+	attrStreamFlags        = 22
+	attrSegmentsCount      = 25
+	attrSegmentsMap        = 26
+	attrOutputFileName     = 27
+	attrVolumeName         = 32
+	attrAudioChannelLayout = 40
+)
+
+// Stream flag bits from attribute 22 (AP_AVStreamFlag_*)
+const (
+	streamFlagDirectorsComments    = 1
+	streamFlagAltDirectorsComments = 2
+	streamFlagCoreAudio            = 256
+	streamFlagForcedSubtitles      = 4096
+)
+
+// StreamKind is the type of an elementary stream
+type StreamKind string
+
+const (
+	StreamVideo    StreamKind = "video"
+	StreamAudio    StreamKind = "audio"
+	StreamSubtitle StreamKind = "subtitle"
+)
+
+// Stream is one video, audio or subtitle track of a title
+type Stream struct {
+	Index        int
+	Kind         StreamKind
+	Name         string // e.g. "Surround 5.1"
+	CodecID      string // e.g. "A_DTS"
+	Codec        string // Short codec name, e.g. "DTS-HD MA"
+	CodecLong    string
+	Language     string // ISO 639-2 code, e.g. "eng"
+	LanguageName string
+	Bitrate      string // As reported, e.g. "3.5 Mb/s"
+
+	// Audio
+	Channels      int
+	ChannelLayout string // e.g. "5.1(side)"
+	SampleRate    int
+
+	// Video
+	Resolution  string // e.g. "1920x1080"
+	AspectRatio string // e.g. "16:9"
+	FrameRate   string // e.g. "23.976 (24000/1001)"
+
+	// Flags
+	Forced     bool // Forced subtitles only
+	Commentary bool // Director's comments
+	CoreAudio  bool // Core of an HD audio track (e.g. AC3 core of TrueHD)
+}
+
+// Width and height of a video stream, or 0 if unknown
+func (s Stream) Dimensions() (width, height int) {
+	w, h, ok := strings.Cut(s.Resolution, "x")
+	if !ok {
+		return 0, 0
+	}
+	width, _ = strconv.Atoi(w)
+	height, _ = strconv.Atoi(h)
+	return width, height
+}
+
+// streamsOf returns the streams of one kind
+func streamsOf(streams []Stream, kind StreamKind) []Stream {
+	var out []Stream
+	for _, s := range streams {
+		if s.Kind == kind {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// VideoStreams returns the title's video tracks
+func (t Title) VideoStreams() []Stream {
+	return streamsOf(t.Streams, StreamVideo)
+}
+
+// AudioStreams returns the title's audio tracks
+func (t Title) AudioStreams() []Stream {
+	return streamsOf(t.Streams, StreamAudio)
+}
+
+// SubtitleStreams returns the title's subtitle tracks
+func (t Title) SubtitleStreams() []Stream {
+	return streamsOf(t.Streams, StreamSubtitle)
+}
+
+// Resolution returns the resolution of the main video track, or ""
+func (t Title) Resolution() string {
+	for _, s := range t.Streams {
+		if s.Kind == StreamVideo {
+			return s.Resolution
+		}
+	}
+	return ""
+}
+
+// AudioLanguages returns the distinct audio languages in track order
+func (t Title) AudioLanguages() []string {
+	var langs []string
+	seen := make(map[string]bool)
+	for _, s := range t.AudioStreams() {
+		if s.Language == "" || seen[s.Language] {
+			continue
+		}
+		seen[s.Language] = true
+		langs = append(langs, s.Language)
+	}
+	return langs
+}
+
+// parseStreamInfo applies one SINFO attribute to its stream
+func parseStreamInfo(s *Stream, attributeID int, code int, value string) {
+	switch attributeID {
+	case attrType:
+		switch {
+		case code == 6201 || value == "Video":
+			s.Kind = StreamVideo
+		case code == 6202 || value == "Audio":
+			s.Kind = StreamAudio
+		case code == 6203 || value == "Subtitles":
+			s.Kind = StreamSubtitle
+		}
+	case attrName:
+		s.Name = value
+	case attrLangCode:
+		s.Language = value
+	case attrLangName:
+		s.LanguageName = value
+	case attrCodecID:
+		s.CodecID = value
+	case attrCodecShort:
+		s.Codec = value
+	case attrCodecLong:
+		s.CodecLong = value
+	case attrBitrate:
+		s.Bitrate = value
+	case attrAudioChannelsCount:
+		s.Channels, _ = strconv.Atoi(value)
+	case attrAudioSampleRate:
+		s.SampleRate, _ = strconv.Atoi(value)
+	case attrAudioChannelLayout:
+		s.ChannelLayout = value
+	case attrVideoSize:
+		s.Resolution = value
+	case attrVideoAspectRatio:
+		s.AspectRatio = value
+	case attrVideoFrameRate:
+		s.FrameRate = value
+	case attrStreamFlags:
+		flags, _ := strconv.Atoi(value)
+		s.Forced = flags&streamFlagForcedSubtitles != 0
+		s.Commentary = flags&(streamFlagDirectorsComments|streamFlagAltDirectorsComments) != 0
+		s.CoreAudio = flags&streamFlagCoreAudio != 0
+	}
+}
+
+// parseSegmentMap parses a segment map like "1,2,5-7" into segment numbers
+func parseSegmentMap(value string) []int {
+	var segments []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if from, to, ok := strings.Cut(part, "-"); ok {
+			start, err1 := strconv.Atoi(from)
+			end, err2 := strconv.Atoi(to)
+			if err1 != nil || err2 != nil || end < start {
+				continue
+			}
+			for n := start; n <= end; n++ {
+				segments = append(segments, n)
+			}
+			continue
+		}
+		if n, err := strconv.Atoi(part); err == nil {
+			segments = append(segments, n)
+		}
+	}
+	return segments
+}
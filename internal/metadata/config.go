@@ -0,0 +1,19 @@
+package metadata
+
+import (
+	"fmt"
+
+	"github.com/mmzim/mkvauto/internal/config"
+)
+
+// New builds the configured provider, or nil if metadata lookup is disabled
+func New(cfg config.MetadataConfig) (Provider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "tmdb":
+		return NewTMDB(cfg.APIKey, cfg.Language), nil
+	default:
+		return nil, fmt.Errorf("unknown metadata provider %q", cfg.Provider)
+	}
+}
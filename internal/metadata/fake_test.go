@@ -0,0 +1,43 @@
+package metadata
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Fake is an offline provider backed by a fixed list of entries
+type Fake struct {
+	entries []Match
+}
+
+func NewFake(entries []Match) *Fake {
+	return &Fake{entries: entries}
+}
+
+func (f *Fake) SearchMovie(ctx context.Context, title string, runtime time.Duration) ([]Match, error) {
+	matches := f.search(KindMovie, title)
+	if runtime > 0 {
+		rankByRuntime(matches, runtime)
+	}
+	return matches, nil
+}
+
+func (f *Fake) SearchTV(ctx context.Context, title string) ([]Match, error) {
+	return f.search(KindTV, title), nil
+}
+
+// search returns entries of the kind whose title contains the query, ignoring case
+func (f *Fake) search(kind Kind, title string) []Match {
+	query := strings.ToLower(strings.TrimSpace(title))
+	var matches []Match
+	for _, e := range f.entries {
+		if e.Kind != kind {
+			continue
+		}
+		if query == "" || strings.Contains(strings.ToLower(e.Title), query) {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
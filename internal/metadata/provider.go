@@ -0,0 +1,100 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Kind distinguishes movies from TV shows
+type Kind string
+
+const (
+	KindMovie Kind = "movie"
+	KindTV    Kind = "tv"
+)
+
+// Match is one search result from a metadata provider
+type Match struct {
+	ID       string        `json:"id"`
+	Kind     Kind          `json:"kind"`
+	Title    string        `json:"title"`
+	Year     int           `json:"year,omitempty"`
+	Runtime  time.Duration `json:"runtime,omitempty"` // Movie runtime or typical episode length
+	Overview string        `json:"overview,omitempty"`
+}
+
+// String returns the display name, e.g. "Star Wars (1977)"
+func (m Match) String() string {
+	if m.Year == 0 {
+		return m.Title
+	}
+	return fmt.Sprintf("%s (%d)", m.Title, m.Year)
+}
+
+// Provider searches a movie/TV database
+type Provider interface {
+	// SearchMovie returns movies matching the title, best match first
+	// A non-zero runtime is used to rank results of equal relevance
+	SearchMovie(ctx context.Context, title string, runtime time.Duration) ([]Match, error)
+
+	// SearchTV returns shows matching the title, best match first
+	SearchTV(ctx context.Context, title string) ([]Match, error)
+}
+
+var (
+	// Disc number and side markers: D1, DISC_2, DISC2, CD1, SIDE_A
+	discMarker = regexp.MustCompile(`(?i)\b(d|disc|disk|cd|dvd|side)[ _-]?([0-9]+|[ab])\b`)
+	// Packaging noise commonly found on volume labels
+	labelNoise = regexp.MustCompile(`(?i)\b(bluray|blu[ _-]?ray|bd|uhd|4k|dvd|ws|fs|widescreen|fullscreen|16x9|4x3|ntsc|pal|special[ _]edition|collectors[ _]edition)\b`)
+	// Season markers: S1, SEASON_2, S01
+	seasonMarker = regexp.MustCompile(`(?i)\b(s|season)[ _-]?([0-9]{1,2})\b`)
+)
+
+// CleanLabel turns a volume label like "STAR_WARS_D1" into a search query like "Star Wars"
+func CleanLabel(label string) string {
+	s := strings.NewReplacer("_", " ", ".", " ").Replace(label)
+	s = discMarker.ReplaceAllString(s, " ")
+	s = seasonMarker.ReplaceAllString(s, " ")
+	s = labelNoise.ReplaceAllString(s, " ")
+	s = strings.Join(strings.Fields(s), " ")
+
+	// Labels are usually all caps; title-case them for display
+	if s == strings.ToUpper(s) {
+		words := strings.Fields(strings.ToLower(s))
+		for i, w := range words {
+			r := []rune(w)
+			r[0] = unicode.ToUpper(r[0])
+			words[i] = string(r)
+		}
+		s = strings.Join(words, " ")
+	}
+
+	return s
+}
+
+// Filename returns a filesystem-safe name that keeps spaces and punctuation
+// Plex and Jellyfin expect "Movie Name (Year)" for folders and files
+func Filename(name string) string {
+	name = strings.ReplaceAll(name, ": ", " - ")
+	replacer := strings.NewReplacer(
+		"/", "-",
+		"\\", "-",
+		":", "-",
+		"*", "",
+		"?", "",
+		"\"", "'",
+		"<", "",
+		">", "",
+		"|", "-",
+	)
+	name = strings.TrimSpace(replacer.Replace(name))
+	name = strings.Trim(name, ".")
+	if name == "" {
+		return "Unnamed"
+	}
+	return name
+}
@@ -0,0 +1,132 @@
+package metadata
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// The fake must satisfy the interface the app uses
+var _ Provider = (*Fake)(nil)
+
+func TestCleanLabel(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{"STAR_WARS_D1", "Star Wars"},
+		{"STAR_WARS_DISC_2", "Star Wars"},
+		{"THE_MATRIX_BLURAY", "The Matrix"},
+		{"LOST_SEASON_1_DISC_3", "Lost"},
+		{"LOST_S01_D2", "Lost"},
+		{"ALIEN_SPECIAL_EDITION_WS", "Alien"},
+		{"MOVIE.NAME.UHD", "Movie Name"},
+		{"Blade Runner 2049", "Blade Runner 2049"},
+		{"ARRIVAL_SIDE_A", "Arrival"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := CleanLabel(tt.label); got != tt.want {
+			t.Errorf("CleanLabel(%q) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Star Wars (1977)", "Star Wars (1977)"},
+		{"Mission: Impossible (1996)", "Mission - Impossible (1996)"},
+		{"AC/DC: Live", "AC-DC - Live"},
+		{`What? "Really" <yes>`, "What 'Really' yes"},
+		{"a|b*c", "a-bc"},
+		{"...", "Unnamed"},
+		{"  ", "Unnamed"},
+	}
+	for _, tt := range tests {
+		if got := Filename(tt.name); got != tt.want {
+			t.Errorf("Filename(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchString(t *testing.T) {
+	if got := (Match{Title: "Star Wars", Year: 1977}).String(); got != "Star Wars (1977)" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (Match{Title: "Untitled"}).String(); got != "Untitled" {
+		t.Errorf("String() without year = %q", got)
+	}
+}
+
+func TestRankByRuntime(t *testing.T) {
+	tests := []struct {
+		name    string
+		runtime time.Duration
+		matches []Match
+		want    []string
+	}{
+		{
+			name:    "closest runtime first",
+			runtime: 121 * time.Minute,
+			matches: []Match{
+				{ID: "remake", Runtime: 95 * time.Minute},
+				{ID: "original", Runtime: 121 * time.Minute},
+				{ID: "documentary", Runtime: 52 * time.Minute},
+			},
+			want: []string{"original", "remake", "documentary"},
+		},
+		{
+			name:    "within a few minutes keeps search order",
+			runtime: 120 * time.Minute,
+			matches: []Match{
+				{ID: "first", Runtime: 123 * time.Minute},
+				{ID: "second", Runtime: 121 * time.Minute},
+			},
+			want: []string{"first", "second"},
+		},
+		{
+			name:    "unknown runtime goes last",
+			runtime: 90 * time.Minute,
+			matches: []Match{
+				{ID: "unknown"},
+				{ID: "far", Runtime: 150 * time.Minute},
+				{ID: "close", Runtime: 91 * time.Minute},
+			},
+			want: []string{"close", "far", "unknown"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rankByRuntime(tt.matches, tt.runtime)
+			for i, id := range tt.want {
+				if tt.matches[i].ID != id {
+					t.Fatalf("position %d = %s, want %s (%v)", i, tt.matches[i].ID, id, tt.matches)
+				}
+			}
+		})
+	}
+}
+
+func TestFakeSearch(t *testing.T) {
+	fake := NewFake([]Match{
+		{ID: "1", Kind: KindMovie, Title: "Star Wars", Year: 1977, Runtime: 121 * time.Minute},
+		{ID: "2", Kind: KindMovie, Title: "Star Wars: The Last Jedi", Year: 2017, Runtime: 152 * time.Minute},
+		{ID: "3", Kind: KindTV, Title: "Star Wars: The Clone Wars", Year: 2008},
+	})
+
+	movies, err := fake.SearchMovie(context.Background(), CleanLabel("STAR_WARS_D1"), 150*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movies) != 2 || movies[0].ID != "2" {
+		t.Errorf("SearchMovie = %v, want the 152 minute movie first", movies)
+	}
+
+	shows, _ := fake.SearchTV(context.Background(), "clone wars")
+	if len(shows) != 1 || shows[0].Kind != KindTV {
+		t.Errorf("SearchTV = %v, want only the show", shows)
+	}
+}
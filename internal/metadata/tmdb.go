@@ -0,0 +1,187 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tmdbBaseURL = "https://api.themoviedb.org/3"
+
+// detailLookups limits how many search results get a runtime lookup
+const detailLookups = 5
+
+// TMDB searches The Movie Database
+type TMDB struct {
+	apiKey   string
+	language string
+	baseURL  string
+	client   *http.Client
+}
+
+// NewTMDB creates a TMDB provider
+// apiKey may be a v3 API key or a v4 read access token
+func NewTMDB(apiKey, language string) *TMDB {
+	return &TMDB{
+		apiKey:   apiKey,
+		language: language,
+		baseURL:  tmdbBaseURL,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type tmdbMovie struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	ReleaseDate string `json:"release_date"`
+	Overview    string `json:"overview"`
+	Runtime     int    `json:"runtime"` // Only set on detail lookups
+}
+
+type tmdbShow struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	FirstAirDate   string `json:"first_air_date"`
+	Overview       string `json:"overview"`
+	EpisodeRunTime []int  `json:"episode_run_time"` // Only set on detail lookups
+}
+
+// SearchMovie searches movies and ranks the top results by runtime distance
+func (t *TMDB) SearchMovie(ctx context.Context, title string, runtime time.Duration) ([]Match, error) {
+	var result struct {
+		Results []tmdbMovie `json:"results"`
+	}
+	if err := t.get(ctx, "/search/movie", url.Values{"query": {title}}, &result); err != nil {
+		return nil, err
+	}
+
+	matches := make([]Match, len(result.Results))
+	for i, r := range result.Results {
+		matches[i] = Match{
+			ID:       strconv.Itoa(r.ID),
+			Kind:     KindMovie,
+			Title:    r.Title,
+			Year:     yearOf(r.ReleaseDate),
+			Overview: r.Overview,
+		}
+	}
+
+	if runtime <= 0 {
+		return matches, nil
+	}
+
+	// Search results carry no runtime, so look up the most relevant few
+	top := matches
+	if len(top) > detailLookups {
+		top = top[:detailLookups]
+	}
+	for i := range top {
+		var detail tmdbMovie
+		if err := t.get(ctx, "/movie/"+top[i].ID, nil, &detail); err != nil {
+			continue
+		}
+		top[i].Runtime = time.Duration(detail.Runtime) * time.Minute
+	}
+
+	rankByRuntime(top, runtime)
+	return matches, nil
+}
+
+// SearchTV searches TV shows
+func (t *TMDB) SearchTV(ctx context.Context, title string) ([]Match, error) {
+	var result struct {
+		Results []tmdbShow `json:"results"`
+	}
+	if err := t.get(ctx, "/search/tv", url.Values{"query": {title}}, &result); err != nil {
+		return nil, err
+	}
+
+	matches := make([]Match, len(result.Results))
+	for i, r := range result.Results {
+		matches[i] = Match{
+			ID:       strconv.Itoa(r.ID),
+			Kind:     KindTV,
+			Title:    r.Name,
+			Year:     yearOf(r.FirstAirDate),
+			Overview: r.Overview,
+		}
+	}
+	return matches, nil
+}
+
+// get performs an authenticated GET request and decodes the JSON response
+func (t *TMDB) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	if t.language != "" {
+		query.Set("language", t.language)
+	}
+
+	// v4 read access tokens are JWTs; v3 keys go in the query string
+	bearer := strings.Count(t.apiKey, ".") == 2
+	if !bearer {
+		query.Set("api_key", t.apiKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create TMDB request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("TMDB request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("TMDB returned status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode TMDB response: %w", err)
+	}
+	return nil
+}
+
+// rankByRuntime moves results closest to the disc runtime first
+// Results without a runtime keep their relative order after those with one
+func rankByRuntime(matches []Match, runtime time.Duration) {
+	distance := func(m Match) time.Duration {
+		if m.Runtime == 0 {
+			return time.Duration(1<<62 - 1)
+		}
+		d := m.Runtime - runtime
+		if d < 0 {
+			d = -d
+		}
+		// Runtimes within a few minutes are equally good (credits, studio logos)
+		return d / (5 * time.Minute)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return distance(matches[i]) < distance(matches[j])
+	})
+}
+
+// yearOf returns the year of a YYYY-MM-DD date, or 0
+func yearOf(date string) int {
+	if len(date) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(date[:4])
+	if err != nil {
+		return 0
+	}
+	return year
+}
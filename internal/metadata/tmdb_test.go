@@ -0,0 +1,141 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestTMDB serves canned TMDB responses by path
+func newTestTMDB(t *testing.T, apiKey string, responses map[string]string) (*TMDB, *[]*http.Request) {
+	t.Helper()
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	tmdb := NewTMDB(apiKey, "en-US")
+	tmdb.baseURL = server.URL
+	return tmdb, &requests
+}
+
+func TestTMDBSearchMovie(t *testing.T) {
+	tmdb, requests := newTestTMDB(t, "v3key", map[string]string{
+		"/search/movie": `{"results": [
+			{"id": 1893, "title": "Star Wars: Episode I", "release_date": "1999-05-19", "overview": "Prequel"},
+			{"id": 11, "title": "Star Wars", "release_date": "1977-05-25", "overview": "Original"},
+			{"id": 99, "title": "Star Wars Special", "release_date": ""}
+		]}`,
+		"/movie/1893": `{"id": 1893, "runtime": 136}`,
+		"/movie/11":   `{"id": 11, "runtime": 121}`,
+	})
+
+	matches, err := tmdb.SearchMovie(context.Background(), "Star Wars", 122*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("got %d matches, want 3", len(matches))
+	}
+
+	want := Match{ID: "11", Kind: KindMovie, Title: "Star Wars", Year: 1977, Runtime: 121 * time.Minute, Overview: "Original"}
+	if matches[0] != want {
+		t.Errorf("best match = %+v, want %+v", matches[0], want)
+	}
+	// A failed detail lookup leaves the runtime unknown, ranked last
+	if matches[2].ID != "99" || matches[2].Runtime != 0 || matches[2].Year != 0 {
+		t.Errorf("last match = %+v, want the one without details", matches[2])
+	}
+
+	search := (*requests)[0].URL.Query()
+	if search.Get("query") != "Star Wars" || search.Get("api_key") != "v3key" || search.Get("language") != "en-US" {
+		t.Errorf("search query = %v", search)
+	}
+}
+
+func TestTMDBSearchMovieWithoutRuntime(t *testing.T) {
+	tmdb, requests := newTestTMDB(t, "v3key", map[string]string{
+		"/search/movie": `{"results": [{"id": 11, "title": "Star Wars", "release_date": "1977-05-25"}]}`,
+	})
+
+	matches, err := tmdb.SearchMovie(context.Background(), "Star Wars", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Runtime != 0 {
+		t.Errorf("matches = %+v", matches)
+	}
+	if len(*requests) != 1 {
+		t.Errorf("made %d requests, want no detail lookups", len(*requests))
+	}
+}
+
+func TestTMDBSearchTV(t *testing.T) {
+	tmdb, _ := newTestTMDB(t, "v3key", map[string]string{
+		"/search/tv": `{"results": [{"id": 4607, "name": "Lost", "first_air_date": "2004-09-22", "overview": "Island"}]}`,
+	})
+
+	matches, err := tmdb.SearchTV(context.Background(), "Lost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Match{ID: "4607", Kind: KindTV, Title: "Lost", Year: 2004, Overview: "Island"}
+	if len(matches) != 1 || matches[0] != want {
+		t.Errorf("matches = %+v, want %+v", matches, want)
+	}
+}
+
+func TestTMDBBearerToken(t *testing.T) {
+	token := "header.payload.signature"
+	tmdb, requests := newTestTMDB(t, token, map[string]string{
+		"/search/tv": `{"results": []}`,
+	})
+
+	if _, err := tmdb.SearchTV(context.Background(), "Lost"); err != nil {
+		t.Fatal(err)
+	}
+	r := (*requests)[0]
+	if got := r.Header.Get("Authorization"); got != "Bearer "+token {
+		t.Errorf("Authorization = %q", got)
+	}
+	if r.URL.Query().Has("api_key") {
+		t.Errorf("v4 token leaked into the query string")
+	}
+}
+
+func TestTMDBErrors(t *testing.T) {
+	tmdb, _ := newTestTMDB(t, "v3key", map[string]string{
+		"/search/tv": `not json`,
+	})
+
+	if _, err := tmdb.SearchMovie(context.Background(), "Missing", 0); err == nil {
+		t.Error("expected an error for a non-200 response")
+	}
+	if _, err := tmdb.SearchTV(context.Background(), "Lost"); err == nil {
+		t.Error("expected an error for an invalid body")
+	}
+}
+
+func TestYearOf(t *testing.T) {
+	tests := map[string]int{
+		"1977-05-25": 1977,
+		"2004":       2004,
+		"":           0,
+		"abcd-01-01": 0,
+	}
+	for date, want := range tests {
+		if got := yearOf(date); got != want {
+			t.Errorf("yearOf(%q) = %d, want %d", date, got, want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"fmt"
+
+	"github.com/mmzim/mkvauto/internal/config"
+)
+
+// Destination is a configured notifier with a stable name
+// The name ties spooled deliveries to their destination across restarts
+type Destination struct {
+	Name     string
+	Notifier Notifier
+}
+
+// FromConfig builds all configured destinations
+func FromConfig(cfg *config.Config) ([]Destination, error) {
+	var destinations []Destination
+
+	// Legacy top-level webhook
+	if cfg.DiscordWebhook != "" {
+		destinations = append(destinations, Destination{
+			Name:     "discord_webhook",
+			Notifier: NewDiscordWebhook(cfg.DiscordWebhook),
+		})
+	}
+
+	for i, nc := range cfg.Notifiers {
+		n, err := New(nc)
+		if err != nil {
+			return nil, fmt.Errorf("notifiers[%d]: %w", i, err)
+		}
+		if n == nil {
+			continue
+		}
+		destinations = append(destinations, Destination{
+			Name:     fmt.Sprintf("%s#%d", nc.Type, i),
+			Notifier: n,
+		})
+	}
+
+	return destinations, nil
+}
+
+// New builds a single notifier, filtered to its configured events
+// Returns nil for type "none"
+func New(nc config.NotifierConfig) (Notifier, error) {
+	// These have no default server to fall back to
+	switch nc.Type {
+	case "discord", "webhook", "gotify":
+		if nc.URL == "" {
+			return nil, fmt.Errorf("url is required for %s", nc.Type)
+		}
+	}
+
+	var n Notifier
+	switch nc.Type {
+	case "discord":
+		n = NewDiscordWebhook(nc.URL)
+	case "webhook":
+		n = NewWebhook(nc.URL, nc.Headers)
+	case "ntfy":
+		if nc.Topic == "" {
+			return nil, fmt.Errorf("topic is required for ntfy")
+		}
+		n = NewNtfy(nc.URL, nc.Topic, nc.Token)
+	case "gotify":
+		if nc.Token == "" {
+			return nil, fmt.Errorf("token is required for gotify")
+		}
+		n = NewGotify(nc.URL, nc.Token)
+	case "email":
+		if nc.SMTPHost == "" || nc.From == "" || len(nc.To) == 0 {
+			return nil, fmt.Errorf("smtp_host, from and to are required for email")
+		}
+		n = NewEmail(nc.SMTPHost, nc.SMTPPort, nc.Username, nc.Password, nc.From, nc.To)
+	case "none":
+		return nil, nil
+	case "":
+		return nil, fmt.Errorf("type is required")
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", nc.Type)
+	}
+
+	kinds := make(map[EventKind]bool, len(nc.Events))
+	for _, e := range nc.Events {
+		if !knownEvent(EventKind(e)) {
+			return nil, fmt.Errorf("unknown event %q", e)
+		}
+		kinds[EventKind(e)] = true
+	}
+	if len(kinds) == 0 {
+		return n, nil
+	}
+	return &Filtered{Notifier: n, Kinds: kinds}, nil
+}
+
+// knownEvent reports whether kind is one of AllEvents
+func knownEvent(kind EventKind) bool {
+	for _, k := range AllEvents {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
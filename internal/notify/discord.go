@@ -2,19 +2,27 @@ package notify
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 )
 
 const (
-	ColorGreen = 3066993  // Success
-	ColorBlue  = 5793266  // Info
-	ColorRed   = 15158332 // Error
+	ColorGreen  = 3066993  // Success
+	ColorBlue   = 5793266  // Info
+	ColorRed    = 15158332 // Error
+	ColorYellow = 16776960 // Attention
 )
 
 type DiscordWebhook struct {
 	webhookURL string
+
+	mu           sync.Mutex
+	blockedUntil time.Time // Rate limit bucket exhausted until this time
 }
 
 func NewDiscordWebhook(webhookURL string) *DiscordWebhook {
@@ -23,83 +31,139 @@ func NewDiscordWebhook(webhookURL string) *DiscordWebhook {
 	}
 }
 
-// SendRipComplete sends a notification when disc ripping is complete
-func (dw *DiscordWebhook) SendRipComplete(discName string, titlesRipped int, discType string) error {
+// Notify sends the event as a Discord embed
+func (dw *DiscordWebhook) Notify(ctx context.Context, event Event) error {
 	embed := map[string]interface{}{
-		"title":       "✅ Rip Complete",
-		"description": fmt.Sprintf("**%s** (%s)\n%d title(s) ripped and queued for encoding", discName, discType, titlesRipped),
-		"color":       ColorGreen,
+		"title":       discordTitle(event),
+		"description": discordDescription(event),
+		"color":       discordColor(event.Kind),
 	}
 
-	return dw.sendEmbed(embed)
+	return dw.sendEmbed(ctx, embed)
 }
 
-// SendEncodeComplete sends a notification when encoding is complete
-func (dw *DiscordWebhook) SendEncodeComplete(filename string, discType string) error {
-	embed := map[string]interface{}{
-		"title":       "🎬 Encode Complete",
-		"description": fmt.Sprintf("**%s**\nProfile: %s → AV1", filename, discType),
-		"color":       ColorBlue,
+// discordTitle prefixes the event title with an emoji
+func discordTitle(event Event) string {
+	switch event.Kind {
+	case EventRipComplete:
+		return "✅ " + event.Title
+	case EventEncodeComplete:
+		return "🎬 " + event.Title
+	case EventError:
+		return "❌ " + event.Title
+	case EventDiscInserted:
+		return "💿 " + event.Title
+	case EventQueueDrained:
+		return "🏁 " + event.Title
+	default:
+		return event.Title
 	}
-
-	return dw.sendEmbed(embed)
 }
 
-// SendError sends an error notification
-func (dw *DiscordWebhook) SendError(operation string, errorMsg string) error {
-	embed := map[string]interface{}{
-		"title":       "❌ Error",
-		"description": fmt.Sprintf("**%s failed**\n```\n%s\n```", operation, errorMsg),
-		"color":       ColorRed,
+// discordDescription formats the message with Discord markdown
+func discordDescription(event Event) string {
+	f := event.Fields
+	switch event.Kind {
+	case EventRipComplete:
+		return fmt.Sprintf("**%s** (%s)\n%s title(s) ripped and queued for encoding", f["disc"], f["disc_type"], f["titles"])
+	case EventEncodeComplete:
+		return fmt.Sprintf("**%s**\nProfile: %s → AV1", f["file"], f["disc_type"])
+	case EventError:
+		return fmt.Sprintf("**%s failed**\n```\n%s\n```", f["operation"], f["error"])
+	default:
+		return event.Message
 	}
+}
 
-	return dw.sendEmbed(embed)
+func discordColor(kind EventKind) int {
+	switch kind {
+	case EventRipComplete, EventQueueDrained:
+		return ColorGreen
+	case EventError:
+		return ColorRed
+	case EventDiscInserted:
+		return ColorYellow
+	default:
+		return ColorBlue
+	}
 }
 
 // sendEmbed sends a Discord embed message
-func (dw *DiscordWebhook) sendEmbed(embed map[string]interface{}) error {
+func (dw *DiscordWebhook) sendEmbed(ctx context.Context, embed map[string]interface{}) error {
 	payload := map[string]interface{}{
 		"embeds": []map[string]interface{}{embed},
 	}
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal webhook payload: %w", err)
-	}
-
-	resp, err := http.Post(dw.webhookURL, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to send webhook: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
-	}
-
-	return nil
+	return dw.post(ctx, payload)
 }
 
 // SendMessage sends a simple text message (no embed)
-func (dw *DiscordWebhook) SendMessage(message string) error {
+func (dw *DiscordWebhook) SendMessage(ctx context.Context, message string) error {
 	payload := map[string]string{
 		"content": message,
 	}
 
+	return dw.post(ctx, payload)
+}
+
+// post sends a JSON payload to the webhook
+// Honours Discord's rate limit headers and 429 retry_after
+func (dw *DiscordWebhook) post(ctx context.Context, payload interface{}) error {
+	dw.mu.Lock()
+	wait := time.Until(dw.blockedUntil)
+	dw.mu.Unlock()
+	if wait > 0 {
+		return &RateLimitError{Service: "discord", RetryAfter: wait}
+	}
+
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal webhook payload: %w", err)
 	}
 
-	resp, err := http.Post(dw.webhookURL, "application/json", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dw.webhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send webhook: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	// Bucket used up: hold further messages until it resets
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if after, err := strconv.ParseFloat(resp.Header.Get("X-RateLimit-Reset-After"), 64); err == nil {
+			dw.blockFor(time.Duration(after * float64(time.Second)))
+		}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		var body struct {
+			RetryAfter float64 `json:"retry_after"`
+		}
+		after, ok := parseRetryAfter(resp.Header.Get("Retry-After"))
+		if json.NewDecoder(resp.Body).Decode(&body) == nil && body.RetryAfter > 0 {
+			after, ok = time.Duration(body.RetryAfter*float64(time.Second)), true
+		}
+		if !ok {
+			after = 5 * time.Second
+		}
+		dw.blockFor(after)
+		return &RateLimitError{Service: "discord", RetryAfter: after}
 	}
 
-	return nil
+	return checkStatus(resp, "discord webhook")
+}
+
+// blockFor stops sending until the delay has passed
+func (dw *DiscordWebhook) blockFor(d time.Duration) {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(dw.blockedUntil) {
+		dw.blockedUntil = until
+	}
 }
@@ -0,0 +1,326 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	deliveryTimeout = 30 * time.Second
+	maxAttempts     = 8
+	baseBackoff     = 5 * time.Second
+	maxBackoff      = 10 * time.Minute
+)
+
+// Delivery is one event waiting to be sent to one destination
+type Delivery struct {
+	ID          string    `json:"id"`
+	Destination string    `json:"destination"`
+	Event       Event     `json:"event"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// Dispatcher delivers events in the background
+// Failed deliveries are retried with exponential backoff and spooled to disk
+// so they survive a restart
+type Dispatcher struct {
+	order        []string
+	destinations map[string]Notifier
+	spoolPath    string
+
+	mu      sync.Mutex
+	pending []*Delivery
+	logf    func(string) // Set by Run
+	wakeCh  chan struct{}
+}
+
+func NewDispatcher(destinations []Destination, spoolPath string) *Dispatcher {
+	d := &Dispatcher{
+		destinations: make(map[string]Notifier, len(destinations)),
+		spoolPath:    spoolPath,
+		wakeCh:       make(chan struct{}, 1),
+	}
+	for _, dest := range destinations {
+		d.order = append(d.order, dest.Name)
+		d.destinations[dest.Name] = dest.Notifier
+	}
+	return d
+}
+
+// Notify queues the event for every destination that wants it
+// Never blocks on the network; spool write errors are also reported to the log
+func (d *Dispatcher) Notify(ctx context.Context, event Event) error {
+	d.mu.Lock()
+	queued := false
+	for _, name := range d.order {
+		n := d.destinations[name]
+		if f, ok := n.(*Filtered); ok && !f.Kinds[event.Kind] {
+			continue
+		}
+		d.pending = append(d.pending, &Delivery{
+			ID:          uuid.New().String(),
+			Destination: name,
+			Event:       event,
+			NextAttempt: time.Now(),
+		})
+		queued = true
+	}
+	var err error
+	if queued {
+		err = d.saveLocked()
+		if err != nil && d.logf != nil {
+			d.logf(fmt.Sprintf("Notification spool: %v", err))
+		}
+	}
+	d.mu.Unlock()
+
+	d.wake()
+	return err
+}
+
+// Pending returns the number of undelivered notifications
+func (d *Dispatcher) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.pending)
+}
+
+// Run loads the spool and delivers notifications until ctx is cancelled
+// Delivery problems are reported through logf
+func (d *Dispatcher) Run(ctx context.Context, logf func(string)) {
+	d.mu.Lock()
+	d.logf = logf
+	d.mu.Unlock()
+
+	if err := d.loadSpool(logf); err != nil {
+		logf(fmt.Sprintf("Notification spool: %v", err))
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wakeCh:
+		case <-timer.C:
+		}
+
+		d.deliverDue(ctx, logf)
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(d.nextWait())
+	}
+}
+
+// deliverDue attempts every delivery whose retry time has come
+func (d *Dispatcher) deliverDue(ctx context.Context, logf func(string)) {
+	for _, delivery := range d.due() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		n := d.destinations[delivery.Destination]
+		attemptCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+		err := n.Notify(attemptCtx, delivery.Event)
+		cancel()
+
+		// Shutting down mid-send: keep it spooled for next time
+		if ctx.Err() != nil {
+			return
+		}
+
+		d.finish(delivery, err, logf)
+	}
+}
+
+// due returns deliveries ready to send
+func (d *Dispatcher) due() []*Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	var ready []*Delivery
+	for _, delivery := range d.pending {
+		if !delivery.NextAttempt.After(now) {
+			ready = append(ready, delivery)
+		}
+	}
+	return ready
+}
+
+// finish records the outcome of one attempt
+func (d *Dispatcher) finish(delivery *Delivery, err error, logf func(string)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var rateLimit *RateLimitError
+	switch {
+	case err == nil:
+		d.removeLocked(delivery.ID)
+		if delivery.Attempts > 0 {
+			logf(fmt.Sprintf("Notification %q delivered to %s after %d retries", delivery.Event.Title, delivery.Destination, delivery.Attempts))
+		}
+
+	case errors.As(err, &rateLimit):
+		// Not the destination's fault, so it doesn't count as an attempt
+		delivery.NextAttempt = time.Now().Add(rateLimit.RetryAfter)
+		delivery.LastError = err.Error()
+
+	default:
+		delivery.Attempts++
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= maxAttempts {
+			d.removeLocked(delivery.ID)
+			logf(fmt.Sprintf("Notification %q to %s dropped after %d attempts: %v", delivery.Event.Title, delivery.Destination, delivery.Attempts, err))
+			break
+		}
+		wait := backoff(delivery.Attempts)
+		delivery.NextAttempt = time.Now().Add(wait)
+		logf(fmt.Sprintf("Notification %q to %s failed (attempt %d), retrying in %s: %v", delivery.Event.Title, delivery.Destination, delivery.Attempts, wait, err))
+	}
+
+	if err := d.saveLocked(); err != nil {
+		logf(fmt.Sprintf("Notification spool: %v", err))
+	}
+}
+
+// backoff returns the delay before the next attempt: 5s, 10s, 20s... capped at 10m
+func backoff(attempts int) time.Duration {
+	wait := baseBackoff << (attempts - 1)
+	if wait > maxBackoff || wait <= 0 {
+		return maxBackoff
+	}
+	return wait
+}
+
+// nextWait returns how long until the next delivery is due
+func (d *Dispatcher) nextWait() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	wait := time.Hour
+	for _, delivery := range d.pending {
+		if until := time.Until(delivery.NextAttempt); until < wait {
+			wait = until
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+func (d *Dispatcher) wake() {
+	select {
+	case d.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) removeLocked(id string) {
+	for i, delivery := range d.pending {
+		if delivery.ID == id {
+			d.pending = append(d.pending[:i], d.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// loadSpool adds deliveries left over from the previous run
+func (d *Dispatcher) loadSpool(logf func(string)) error {
+	data, err := os.ReadFile(d.spoolPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read spool: %w", err)
+	}
+
+	var spooled []*Delivery
+	if err := json.Unmarshal(data, &spooled); err != nil {
+		return fmt.Errorf("failed to unmarshal spool: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Events queued before Run started are already in the spool
+	queued := make(map[string]bool, len(d.pending))
+	for _, delivery := range d.pending {
+		queued[delivery.ID] = true
+	}
+
+	var kept []*Delivery
+	dropped := 0
+	for _, delivery := range spooled {
+		if queued[delivery.ID] {
+			continue
+		}
+		if _, ok := d.destinations[delivery.Destination]; !ok {
+			dropped++
+			continue
+		}
+		kept = append(kept, delivery)
+	}
+	if dropped > 0 {
+		logf(fmt.Sprintf("Dropped %d spooled notification(s) for destinations no longer configured", dropped))
+	}
+	if len(kept) > 0 {
+		logf(fmt.Sprintf("Resending %d spooled notification(s)", len(kept)))
+	}
+
+	// Spooled deliveries are older, so they go first
+	d.pending = append(kept, d.pending...)
+	return d.saveLocked()
+}
+
+// saveLocked writes pending deliveries to the spool atomically
+// Removes the spool file when nothing is pending
+func (d *Dispatcher) saveLocked() error {
+	if d.spoolPath == "" {
+		return nil
+	}
+
+	if len(d.pending) == 0 {
+		if err := os.Remove(d.spoolPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove spool: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(d.spoolPath), 0755); err != nil {
+		return fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(d.pending, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal spool: %w", err)
+	}
+
+	tmpPath := d.spoolPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write spool: %w", err)
+	}
+	if err := os.Rename(tmpPath, d.spoolPath); err != nil {
+		return fmt.Errorf("failed to rename spool: %w", err)
+	}
+
+	return nil
+}
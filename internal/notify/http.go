@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// httpClient is shared by all HTTP backends so no request can hang forever
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// RateLimitError is returned when a destination asks us to slow down
+// The delivery should be retried after the given delay
+type RateLimitError struct {
+	Service    string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s rate limited, retry after %s", e.Service, e.RetryAfter.Round(time.Second))
+}
+
+// checkStatus turns a non-2xx response into an error
+// 429 and 503 responses become a RateLimitError using the Retry-After header
+func checkStatus(resp *http.Response, service string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if after, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return &RateLimitError{Service: service, RetryAfter: after}
+		}
+	}
+
+	return fmt.Errorf("%s returned status %d", service, resp.StatusCode)
+}
+
+// parseRetryAfter accepts a delay in seconds (fractions allowed) or an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
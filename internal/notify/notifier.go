@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EventKind identifies what happened
+type EventKind string
+
+const (
+	EventDiscInserted   EventKind = "disc_inserted"
+	EventRipComplete    EventKind = "rip_complete"
+	EventEncodeComplete EventKind = "encode_complete"
+	EventError          EventKind = "error"
+	EventQueueDrained   EventKind = "queue_drained"
+)
+
+// AllEvents lists every event kind, in display order
+var AllEvents = []EventKind{
+	EventDiscInserted,
+	EventRipComplete,
+	EventEncodeComplete,
+	EventError,
+	EventQueueDrained,
+}
+
+// Event is a notification, rendered by each backend in its own format
+type Event struct {
+	Kind    EventKind         `json:"kind"`
+	Title   string            `json:"title"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+	Time    time.Time         `json:"time"`
+}
+
+// Notifier delivers events to one destination
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// DiscInserted builds the event sent when a disc is detected
+func DiscInserted(drive string) Event {
+	return Event{
+		Kind:    EventDiscInserted,
+		Title:   "Disc Inserted",
+		Message: fmt.Sprintf("Disc detected in %s, scanning", drive),
+		Fields:  map[string]string{"drive": drive},
+		Time:    time.Now(),
+	}
+}
+
+// RipComplete builds the event sent when all titles of a disc are ripped
+func RipComplete(discName string, titlesRipped int, discType string) Event {
+	return Event{
+		Kind:    EventRipComplete,
+		Title:   "Rip Complete",
+		Message: fmt.Sprintf("%s (%s)\n%d title(s) ripped and queued for encoding", discName, discType, titlesRipped),
+		Fields: map[string]string{
+			"disc":      discName,
+			"disc_type": discType,
+			"titles":    fmt.Sprintf("%d", titlesRipped),
+		},
+		Time: time.Now(),
+	}
+}
+
+// EncodeComplete builds the event sent when an encode finishes
+func EncodeComplete(filename string, discType string) Event {
+	return Event{
+		Kind:    EventEncodeComplete,
+		Title:   "Encode Complete",
+		Message: fmt.Sprintf("%s\nProfile: %s → AV1", filename, discType),
+		Fields: map[string]string{
+			"file":      filename,
+			"disc_type": discType,
+		},
+		Time: time.Now(),
+	}
+}
+
+// Error builds the event sent when an operation fails
+func Error(operation string, errorMsg string) Event {
+	return Event{
+		Kind:    EventError,
+		Title:   "Error",
+		Message: fmt.Sprintf("%s failed\n%s", operation, errorMsg),
+		Fields: map[string]string{
+			"operation": operation,
+			"error":     errorMsg,
+		},
+		Time: time.Now(),
+	}
+}
+
+// QueueDrained builds the event sent when the encode queue runs empty
+func QueueDrained(completed, failed int) Event {
+	return Event{
+		Kind:    EventQueueDrained,
+		Title:   "Queue Drained",
+		Message: fmt.Sprintf("All encodes finished: %d complete, %d failed", completed, failed),
+		Fields: map[string]string{
+			"completed": fmt.Sprintf("%d", completed),
+			"failed":    fmt.Sprintf("%d", failed),
+		},
+		Time: time.Now(),
+	}
+}
+
+// Multi sends every event to all of its notifiers
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Filtered only passes on events of the given kinds
+type Filtered struct {
+	Notifier Notifier
+	Kinds    map[EventKind]bool
+}
+
+func (f *Filtered) Notify(ctx context.Context, event Event) error {
+	if !f.Kinds[event.Kind] {
+		return nil
+	}
+	return f.Notifier.Notify(ctx, event)
+}
+
+// Noop discards all events
+type Noop struct{}
+
+func (Noop) Notify(ctx context.Context, event Event) error {
+	return nil
+}
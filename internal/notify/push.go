@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Ntfy publishes events to an ntfy topic
+type Ntfy struct {
+	serverURL string
+	topic     string
+	token     string
+}
+
+func NewNtfy(serverURL, topic, token string) *Ntfy {
+	if serverURL == "" {
+		serverURL = "https://ntfy.sh"
+	}
+	return &Ntfy{
+		serverURL: strings.TrimRight(serverURL, "/"),
+		topic:     topic,
+		token:     token,
+	}
+}
+
+// Notify publishes the event message with title, tags and priority headers
+func (n *Ntfy) Notify(ctx context.Context, event Event) error {
+	endpoint := n.serverURL + "/" + url.PathEscape(n.topic)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(event.Message))
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+	req.Header.Set("Title", event.Title)
+	req.Header.Set("Tags", ntfyTag(event.Kind))
+	if event.Kind == EventError {
+		req.Header.Set("Priority", "high")
+	}
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	return doPush(req, "ntfy")
+}
+
+// ntfyTag maps event kinds to ntfy emoji shortcodes
+func ntfyTag(kind EventKind) string {
+	switch kind {
+	case EventRipComplete:
+		return "white_check_mark"
+	case EventEncodeComplete:
+		return "clapper"
+	case EventError:
+		return "x"
+	case EventDiscInserted:
+		return "cd"
+	case EventQueueDrained:
+		return "checkered_flag"
+	default:
+		return string(kind)
+	}
+}
+
+// Gotify sends events to a Gotify server
+type Gotify struct {
+	serverURL string
+	token     string
+}
+
+func NewGotify(serverURL, token string) *Gotify {
+	return &Gotify{
+		serverURL: strings.TrimRight(serverURL, "/"),
+		token:     token,
+	}
+}
+
+// Notify posts the event to /message using the application token
+func (g *Gotify) Notify(ctx context.Context, event Event) error {
+	priority := 5
+	if event.Kind == EventError {
+		priority = 8
+	}
+
+	payload := map[string]interface{}{
+		"title":    event.Title,
+		"message":  event.Message,
+		"priority": priority,
+	}
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal gotify payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.serverURL+"/message", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create gotify request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", g.token)
+
+	return doPush(req, "gotify")
+}
+
+// doPush sends a push request and checks the response status
+func doPush(req *http.Request, service string) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", service, err)
+	}
+	defer resp.Body.Close()
+
+	return checkStatus(resp, service)
+}
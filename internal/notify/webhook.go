@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Webhook posts events as JSON to an arbitrary URL
+type Webhook struct {
+	url     string
+	headers map[string]string
+}
+
+func NewWebhook(url string, headers map[string]string) *Webhook {
+	return &Webhook{
+		url:     url,
+		headers: headers,
+	}
+}
+
+// Notify posts the event as a JSON object
+func (w *Webhook) Notify(ctx context.Context, event Event) error {
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return checkStatus(resp, "webhook")
+}
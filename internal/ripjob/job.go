@@ -0,0 +1,213 @@
+package ripjob
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is the rip state of a single title
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusRipped  Status = "ripped"
+	StatusFailed  Status = "failed"
+)
+
+// Title is one title chosen for ripping
+type Title struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`                // MakeMKV title name, the output name once ripped
+	FileName    string `json:"file_name,omitempty"` // Output name without extension, e.g. "Show - S01E05"
+	Status      Status `json:"status"`
+	RawPath     string `json:"raw_path,omitempty"`
+	EncodedPath string `json:"encoded_path,omitempty"`
+}
+
+// Job records the rip of one disc so it can be resumed after a crash,
+// a restart or a cancelled rip
+type Job struct {
+	Fingerprint string    `json:"fingerprint"`         // Disc identity, see makemkv.ScanResult.Fingerprint
+	VolumeID    string    `json:"volume_id,omitempty"` // Checked with makemkv.SameVolume before resuming
+	DiscName    string    `json:"disc_name"`
+	DiscType    string    `json:"disc_type"`
+	Folder      string    `json:"folder"`               // Output folder relative to output_dir
+	Named       bool      `json:"named"`                // Output is named after a metadata match or episodes
+	Movie       bool      `json:"movie,omitempty"`      // Named after a movie match: MainTitle gets the name, others are extras
+	MainTitle   int       `json:"main_title,omitempty"` // Title ID of the main feature
+	Titles      []Title   `json:"titles"`
+	HistoryID   uint64    `json:"history_id,omitempty"` // Record of this rip in the history database
+	StartedAt   time.Time `json:"started_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Ripped returns how many titles are ripped
+func (j *Job) Ripped() int {
+	n := 0
+	for _, t := range j.Titles {
+		if t.Status == StatusRipped {
+			n++
+		}
+	}
+	return n
+}
+
+// Done reports whether every title is ripped
+func (j *Job) Done() bool {
+	return j.Ripped() == len(j.Titles)
+}
+
+// clone returns a deep copy so callers can't race the store
+func (j *Job) clone() *Job {
+	c := *j
+	c.Titles = append([]Title(nil), j.Titles...)
+	return &c
+}
+
+// Store persists unfinished rip jobs, keyed by disc fingerprint
+type Store struct {
+	path string
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+func NewStore(path string) *Store {
+	return &Store{
+		path: path,
+		jobs: make(map[string]*Job),
+	}
+}
+
+// Load reads the jobs from disk; a missing file means no jobs
+func (s *Store) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.jobs = make(map[string]*Job)
+			return nil
+		}
+		return fmt.Errorf("failed to read rip jobs: %w", err)
+	}
+
+	var jobs []*Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return fmt.Errorf("failed to parse rip jobs: %w", err)
+	}
+	s.jobs = make(map[string]*Job, len(jobs))
+	for _, job := range jobs {
+		s.jobs[job.Fingerprint] = job
+	}
+	return nil
+}
+
+// Get returns a copy of the job for a disc, or nil
+func (s *Store) Get(fingerprint string) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[fingerprint]
+	if !ok {
+		return nil
+	}
+	return job.clone()
+}
+
+// List returns copies of all jobs, oldest first
+func (s *Store) List() []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.listLocked()
+}
+
+// Put adds or replaces the job for its disc
+func (s *Store) Put(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job = job.clone()
+	job.UpdatedAt = time.Now()
+	s.jobs[job.Fingerprint] = job
+	return s.saveLocked()
+}
+
+// SetTitle records the outcome of ripping one title
+func (s *Store) SetTitle(fingerprint string, title Title) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[fingerprint]
+	if !ok {
+		return fmt.Errorf("no rip job for disc %s", fingerprint)
+	}
+	for i := range job.Titles {
+		if job.Titles[i].ID == title.ID {
+			job.Titles[i] = title
+			job.UpdatedAt = time.Now()
+			return s.saveLocked()
+		}
+	}
+	return fmt.Errorf("title %d is not part of the rip job", title.ID)
+}
+
+// Remove forgets the job for a disc
+func (s *Store) Remove(fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[fingerprint]; !ok {
+		return nil
+	}
+	delete(s.jobs, fingerprint)
+	return s.saveLocked()
+}
+
+// listLocked returns copies of all jobs; caller must hold the lock
+func (s *Store) listLocked() []*Job {
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job.clone())
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.Before(jobs[j].StartedAt)
+	})
+	return jobs
+}
+
+// saveLocked writes the jobs to disk atomically; caller must hold the lock
+// The file is removed when no jobs are left
+func (s *Store) saveLocked() error {
+	if len(s.jobs) == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove rip jobs: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s.listLocked(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal rip jobs: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write rip jobs: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to rename rip jobs: %w", err)
+	}
+	return nil
+}
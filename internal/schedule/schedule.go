@@ -0,0 +1,192 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmzim/mkvauto/internal/config"
+)
+
+// Window is a time range on some days of the week
+type Window struct {
+	Days  [7]bool // Indexed by time.Weekday
+	Start int     // Minutes after midnight
+	End   int     // Minutes after midnight, at or before Start crosses midnight
+}
+
+// Schedule holds the windows in which encodes may run
+// A nil Schedule is always open
+type Schedule struct {
+	windows []Window
+}
+
+// FromConfig builds the schedule, or nil when no windows are configured
+func FromConfig(cfg config.ScheduleConfig) (*Schedule, error) {
+	if len(cfg.Windows) == 0 {
+		return nil, nil
+	}
+
+	s := &Schedule{}
+	for i, wc := range cfg.Windows {
+		w, err := ParseWindow(wc.Days, wc.Start, wc.End)
+		if err != nil {
+			return nil, fmt.Errorf("schedule.windows[%d]: %w", i, err)
+		}
+		s.windows = append(s.windows, w)
+	}
+	return s, nil
+}
+
+// ParseWindow parses day names (mon..sun, weekdays, weekends; empty for
+// every day) and HH:MM start and end times (empty for midnight)
+func ParseWindow(days []string, start, end string) (Window, error) {
+	var w Window
+	if len(days) == 0 {
+		days = []string{"weekdays", "weekends"}
+	}
+	for _, d := range days {
+		switch strings.ToLower(d) {
+		case "weekdays":
+			for i := time.Monday; i <= time.Friday; i++ {
+				w.Days[i] = true
+			}
+		case "weekends":
+			w.Days[time.Saturday] = true
+			w.Days[time.Sunday] = true
+		default:
+			day, ok := dayNames[strings.ToLower(d)]
+			if !ok {
+				return w, fmt.Errorf("unknown day %q (use mon..sun, weekdays or weekends)", d)
+			}
+			w.Days[day] = true
+		}
+	}
+
+	var err error
+	if w.Start, err = parseClock(start); err != nil {
+		return w, fmt.Errorf("invalid start: %w", err)
+	}
+	if w.End, err = parseClock(end); err != nil {
+		return w, fmt.Errorf("invalid end: %w", err)
+	}
+	return w, nil
+}
+
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// parseClock parses HH:MM into minutes after midnight
+func parseClock(s string) (int, error) {
+	if s == "" || s == "24:00" {
+		return 0, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Open reports whether encodes may run at t
+func (s *Schedule) Open(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	for _, r := range s.ranges(t) {
+		if !t.Before(r.start) && t.Before(r.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns when the schedule next opens or closes after t
+// The zero time means it never changes (always open, or no window at all)
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	// A range reaching the end of the expanded days never closes
+	y, m, d := t.Date()
+	horizon := time.Date(y, m, d+9, 0, 0, 0, 0, t.Location())
+	for _, r := range s.ranges(t) {
+		if r.start.After(t) {
+			return r.start
+		}
+		if r.end.After(t) {
+			if !r.end.Before(horizon) {
+				return time.Time{}
+			}
+			return r.end
+		}
+	}
+	return time.Time{}
+}
+
+// Describe tells when the schedule changes next, for display
+func (s *Schedule) Describe(t time.Time) string {
+	next := s.Next(t)
+	if next.IsZero() {
+		return ""
+	}
+
+	when := next.Format("15:04")
+	if next.YearDay() != t.YearDay() || next.Year() != t.Year() {
+		when = next.Format("Mon 15:04")
+	}
+	if s.Open(t) {
+		return "encode window open until " + when
+	}
+	return "next encode window opens " + when
+}
+
+type timeRange struct {
+	start, end time.Time
+}
+
+// ranges expands the windows around t into merged, sorted time ranges,
+// from the day before (windows crossing midnight) to a week after
+func (s *Schedule) ranges(t time.Time) []timeRange {
+	y, m, d := t.Date()
+	var ranges []timeRange
+	for offset := -1; offset <= 8; offset++ {
+		day := time.Date(y, m, d+offset, 0, 0, 0, 0, t.Location())
+		for _, w := range s.windows {
+			if !w.Days[day.Weekday()] {
+				continue
+			}
+			endDay := d + offset
+			if w.End <= w.Start {
+				endDay++
+			}
+			ranges = append(ranges, timeRange{
+				start: time.Date(y, m, d+offset, 0, w.Start, 0, 0, t.Location()),
+				end:   time.Date(y, m, endDay, 0, w.End, 0, 0, t.Location()),
+			})
+		}
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start.Before(ranges[j].start) })
+
+	// Merge overlapping and touching ranges, e.g. Saturday and Sunday
+	var merged []timeRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && !r.start.After(merged[n-1].end) {
+			if r.end.After(merged[n-1].end) {
+				merged[n-1].end = r.end
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,167 @@
+package series
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmzim/mkvauto/internal/metadata"
+)
+
+// ErrNoSession is returned when an operation needs an active session
+var ErrNoSession = errors.New("no series session active")
+
+// Session tracks a TV season being ripped over several discs
+type Session struct {
+	Show        string    `json:"show"`
+	Season      int       `json:"season"`
+	NextEpisode int       `json:"next_episode"`
+	Discs       []string  `json:"discs,omitempty"` // Disc labels ripped so far, in order
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// SeasonFolder returns the relative output folder, e.g. "Show/Season 01"
+func (s Session) SeasonFolder() string {
+	return filepath.Join(metadata.Filename(s.Show), fmt.Sprintf("Season %02d", s.Season))
+}
+
+// EpisodeName returns the file name without extension, e.g. "Show - S01E05"
+func (s Session) EpisodeName(episode int) string {
+	return fmt.Sprintf("%s - S%02dE%02d", metadata.Filename(s.Show), s.Season, episode)
+}
+
+// Store persists the active session
+type Store struct {
+	path string
+
+	mu      sync.Mutex
+	session *Session
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Load reads the session from disk; a missing file means no session
+func (s *Store) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.session = nil
+			return nil
+		}
+		return fmt.Errorf("failed to read series session: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return fmt.Errorf("failed to parse series session: %w", err)
+	}
+	s.session = &session
+	return nil
+}
+
+// Current returns a copy of the active session, or nil
+func (s *Store) Current() *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.session == nil {
+		return nil
+	}
+	session := *s.session
+	session.Discs = append([]string(nil), s.session.Discs...)
+	return &session
+}
+
+// Start begins a new session, replacing any active one
+func (s *Store) Start(show string, season, firstEpisode int) (*Session, error) {
+	show = strings.TrimSpace(show)
+	if show == "" {
+		return nil, fmt.Errorf("show name is required")
+	}
+	if season < 0 {
+		return nil, fmt.Errorf("season must not be negative")
+	}
+	if firstEpisode < 1 {
+		firstEpisode = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.session = &Session{
+		Show:        show,
+		Season:      season,
+		NextEpisode: firstEpisode,
+		StartedAt:   time.Now(),
+	}
+	if err := s.saveLocked(); err != nil {
+		return nil, err
+	}
+	session := *s.session
+	return &session, nil
+}
+
+// End finishes the active session
+func (s *Store) End() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.session == nil {
+		return ErrNoSession
+	}
+	s.session = nil
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove series session: %w", err)
+	}
+	return nil
+}
+
+// Reserve claims count episode numbers for a disc and returns the first one
+// Reserving up front keeps numbering stable when several drives rip at once
+func (s *Store) Reserve(discName string, count int) (Session, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.session == nil {
+		return Session{}, 0, ErrNoSession
+	}
+
+	first := s.session.NextEpisode
+	s.session.NextEpisode += count
+	s.session.Discs = append(s.session.Discs, discName)
+	if err := s.saveLocked(); err != nil {
+		return Session{}, 0, err
+	}
+	return *s.session, first, nil
+}
+
+// saveLocked writes the session to disk atomically; caller must hold the lock
+func (s *Store) saveLocked() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s.session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal series session: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write series session: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to rename series session: %w", err)
+	}
+	return nil
+}
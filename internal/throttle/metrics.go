@@ -0,0 +1,82 @@
+package throttle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Metrics is a sample of how busy and hot the machine is
+// Values that can't be read are left at 0, so they never cross a limit
+type Metrics struct {
+	Load        float64 `json:"load"`         // 1-minute load average
+	CPUPressure float64 `json:"cpu_pressure"` // % of time runnable tasks waited for a CPU (avg10)
+	Temperature float64 `json:"temperature"`  // °C of the hottest thermal zone
+}
+
+// Source samples the system metrics
+type Source interface {
+	Sample() (Metrics, error)
+}
+
+// SystemSource reads metrics from /proc and /sys
+type SystemSource struct {
+	procDir string
+	sysDir  string
+}
+
+func NewSystemSource() *SystemSource {
+	return &SystemSource{procDir: "/proc", sysDir: "/sys"}
+}
+
+func (s *SystemSource) Sample() (Metrics, error) {
+	var m Metrics
+
+	// Load is the only metric every kernel has
+	data, err := os.ReadFile(filepath.Join(s.procDir, "loadavg"))
+	if err != nil {
+		return m, fmt.Errorf("failed to read load average: %w", err)
+	}
+	if fields := strings.Fields(string(data)); len(fields) > 0 {
+		m.Load, _ = strconv.ParseFloat(fields[0], 64)
+	}
+
+	// Pressure stall information needs CONFIG_PSI
+	if data, err := os.ReadFile(filepath.Join(s.procDir, "pressure", "cpu")); err == nil {
+		m.CPUPressure = parsePressure(string(data))
+	}
+
+	// Thermal zones report millidegrees Celsius
+	zones, _ := filepath.Glob(filepath.Join(s.sysDir, "class", "thermal", "thermal_zone*", "temp"))
+	for _, zone := range zones {
+		data, err := os.ReadFile(zone)
+		if err != nil {
+			continue
+		}
+		if milli, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64); err == nil {
+			m.Temperature = max(m.Temperature, milli/1000)
+		}
+	}
+
+	return m, nil
+}
+
+// parsePressure reads avg10 of the "some" line of a PSI file:
+// "some avg10=1.23 avg60=0.50 avg300=0.10 total=12345"
+func parsePressure(data string) float64 {
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "some" {
+			continue
+		}
+		for _, f := range fields[1:] {
+			if value, ok := strings.CutPrefix(f, "avg10="); ok {
+				pressure, _ := strconv.ParseFloat(value, 64)
+				return pressure
+			}
+		}
+	}
+	return 0
+}
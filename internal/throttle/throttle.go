@@ -0,0 +1,160 @@
+package throttle
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mmzim/mkvauto/internal/config"
+)
+
+// Action is how encodes are throttled
+type Action string
+
+const (
+	ActionPause Action = "pause" // Suspend running encodes and hold new ones
+	ActionNice  Action = "nice"  // Lower the CPU and I/O priority of the encoder
+)
+
+// State is what encodes should do after a check
+type State int
+
+const (
+	StateRun         State = iota // Full speed
+	StatePause                    // Suspend running encodes and hold new ones
+	StateLowPriority              // Keep encoding at the lowest priority
+)
+
+// Limits are the thresholds that trigger throttling, 0 ignores a metric
+type Limits struct {
+	Load        float64
+	CPUPressure float64
+	Temperature float64
+}
+
+// Throttle watches the metrics and decides when encodes have to back off
+// Once throttled, every metric has to drop clearly below its limit
+// before encodes go back to full speed, so they don't flap at the edge.
+// The metrics include the encodes themselves: pausing them lowers the
+// load, so every change is also kept for at least the dwell time.
+type Throttle struct {
+	source   Source
+	limits   Limits
+	action   Action
+	interval time.Duration
+	dwell    time.Duration
+	now      func() time.Time
+
+	reason  string    // Why encodes are throttled, "" while they aren't
+	changed time.Time // When encodes were last throttled or released
+}
+
+func New(source Source, limits Limits, action Action, interval, dwell time.Duration) *Throttle {
+	return &Throttle{source: source, limits: limits, action: action, interval: interval, dwell: dwell, now: time.Now}
+}
+
+// FromConfig builds the throttle, or nil when no limit is configured
+func FromConfig(cfg config.ThrottleConfig) (*Throttle, error) {
+	if cfg.MaxLoad < 0 || cfg.MaxCPUPressure < 0 || cfg.MaxTemperature < 0 || cfg.Interval < 0 || cfg.MinDwell < 0 {
+		return nil, fmt.Errorf("limits, interval and min_dwell must not be negative")
+	}
+
+	action := Action(cfg.Action)
+	switch action {
+	case "":
+		action = ActionPause
+	case ActionPause, ActionNice:
+	default:
+		return nil, fmt.Errorf("invalid action %q (use pause or nice)", cfg.Action)
+	}
+
+	limits := Limits{Load: cfg.MaxLoad, CPUPressure: cfg.MaxCPUPressure, Temperature: cfg.MaxTemperature}
+	if limits == (Limits{}) {
+		return nil, nil
+	}
+
+	interval := time.Duration(cfg.Interval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	// The 1-minute load average needs a few minutes to settle
+	dwell := time.Duration(cfg.MinDwell) * time.Second
+	if dwell <= 0 {
+		dwell = 5 * time.Minute
+	}
+
+	return New(NewSystemSource(), limits, action, interval, dwell), nil
+}
+
+// Interval returns how often the metrics should be checked
+func (t *Throttle) Interval() time.Duration {
+	return t.interval
+}
+
+// Check samples the metrics and returns what encodes should do, and why
+// they are throttled. A failed sample keeps the previous state.
+func (t *Throttle) Check() (State, string, error) {
+	m, err := t.source.Sample()
+	if err != nil {
+		return t.state(), t.reason, err
+	}
+
+	reason := t.reason
+	if over := t.over(m); over != "" {
+		reason = over
+	} else if reason != "" && t.cooledDown(m) {
+		reason = ""
+	}
+
+	switch {
+	case (reason == "") == (t.reason == ""):
+		t.reason = reason // Same state, maybe other metrics
+	case t.changed.IsZero() || t.now().Sub(t.changed) >= t.dwell:
+		t.reason = reason
+		t.changed = t.now()
+	}
+	return t.state(), t.reason, nil
+}
+
+// state maps the current reason to what encodes should do
+func (t *Throttle) state() State {
+	switch {
+	case t.reason == "":
+		return StateRun
+	case t.action == ActionNice:
+		return StateLowPriority
+	default:
+		return StatePause
+	}
+}
+
+// over describes every metric above its limit
+func (t *Throttle) over(m Metrics) string {
+	var reasons []string
+	if t.limits.Load > 0 && m.Load > t.limits.Load {
+		reasons = append(reasons, fmt.Sprintf("load %.1f above %g", m.Load, t.limits.Load))
+	}
+	if t.limits.CPUPressure > 0 && m.CPUPressure > t.limits.CPUPressure {
+		reasons = append(reasons, fmt.Sprintf("CPU pressure %.0f%% above %g%%", m.CPUPressure, t.limits.CPUPressure))
+	}
+	if t.limits.Temperature > 0 && m.Temperature > t.limits.Temperature {
+		reasons = append(reasons, fmt.Sprintf("temperature %.0f°C above %g°C", m.Temperature, t.limits.Temperature))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// cooledDown reports whether every metric is clearly below its limit:
+// 10% below for load and pressure, 5°C below for temperature
+func (t *Throttle) cooledDown(m Metrics) bool {
+	if t.limits.Load > 0 && m.Load > t.limits.Load*0.9 {
+		return false
+	}
+	if t.limits.CPUPressure > 0 && m.CPUPressure > t.limits.CPUPressure*0.9 {
+		return false
+	}
+	if t.limits.Temperature > 0 && m.Temperature > t.limits.Temperature-5 {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,252 @@
+package throttle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmzim/mkvauto/internal/config"
+)
+
+// fakeSource reports metrics set by the test, or an error
+type fakeSource struct {
+	metrics Metrics
+	err     error
+}
+
+func (s *fakeSource) Sample() (Metrics, error) {
+	return s.metrics, s.err
+}
+
+func TestThresholds(t *testing.T) {
+	limits := Limits{Load: 8, CPUPressure: 50, Temperature: 80}
+	tests := []struct {
+		name    string
+		metrics Metrics
+		want    string // Substrings of the reason, "" for none
+	}{
+		{"all below", Metrics{Load: 4, CPUPressure: 10, Temperature: 60}, ""},
+		{"at the limit", Metrics{Load: 8, CPUPressure: 50, Temperature: 80}, ""},
+		{"load", Metrics{Load: 9.5}, "load 9.5 above 8"},
+		{"pressure", Metrics{CPUPressure: 75}, "CPU pressure 75% above 50%"},
+		{"temperature", Metrics{Temperature: 91}, "temperature 91°C above 80°C"},
+		{"several", Metrics{Load: 10, Temperature: 85}, "load 10.0 above 8, temperature 85°C above 80°C"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := New(&fakeSource{metrics: tt.metrics}, limits, ActionPause, time.Second, 0)
+			_, reason, err := th.Check()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reason != tt.want {
+				t.Errorf("reason = %q, want %q", reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestIgnoredLimits(t *testing.T) {
+	th := New(&fakeSource{metrics: Metrics{Load: 100, CPUPressure: 100}}, Limits{Temperature: 80}, ActionPause, time.Second, 0)
+	if state, reason, _ := th.Check(); state != StateRun {
+		t.Errorf("state = %v (%s), want run with load and pressure ignored", state, reason)
+	}
+}
+
+// step is one sample and the state expected after it
+type step struct {
+	metrics Metrics
+	want    State
+}
+
+func runSteps(t *testing.T, th *Throttle, source *fakeSource, steps []step) {
+	t.Helper()
+	for i, s := range steps {
+		source.metrics = s.metrics
+		state, reason, err := th.Check()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state != s.want {
+			t.Fatalf("step %d (%+v): state = %v (%q), want %v", i, s.metrics, state, reason, s.want)
+		}
+		if (state == StateRun) != (reason == "") {
+			t.Fatalf("step %d: state %v with reason %q", i, state, reason)
+		}
+	}
+}
+
+func TestCooldown(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		steps  []step
+	}{
+		{
+			name:   "load must drop 10% below the limit",
+			limits: Limits{Load: 10},
+			steps: []step{
+				{Metrics{Load: 11}, StatePause},
+				{Metrics{Load: 9.5}, StatePause},
+				{Metrics{Load: 9.1}, StatePause},
+				{Metrics{Load: 8.9}, StateRun},
+				{Metrics{Load: 9.5}, StateRun},
+			},
+		},
+		{
+			name:   "temperature must drop 5°C below the limit",
+			limits: Limits{Temperature: 80},
+			steps: []step{
+				{Metrics{Temperature: 82}, StatePause},
+				{Metrics{Temperature: 78}, StatePause},
+				{Metrics{Temperature: 74}, StateRun},
+			},
+		},
+		{
+			name:   "every metric must cool down",
+			limits: Limits{Load: 10, CPUPressure: 50},
+			steps: []step{
+				{Metrics{Load: 12, CPUPressure: 60}, StatePause},
+				{Metrics{Load: 5, CPUPressure: 48}, StatePause},
+				{Metrics{Load: 5, CPUPressure: 40}, StateRun},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &fakeSource{}
+			runSteps(t, New(source, tt.limits, ActionPause, time.Second, 0), source, tt.steps)
+		})
+	}
+}
+
+func TestActions(t *testing.T) {
+	tests := []struct {
+		action Action
+		steps  []step
+	}{
+		{ActionPause, []step{
+			{Metrics{Load: 2}, StateRun},
+			{Metrics{Load: 6}, StatePause},
+			{Metrics{Load: 2}, StateRun},
+		}},
+		{ActionNice, []step{
+			{Metrics{Load: 2}, StateRun},
+			{Metrics{Load: 6}, StateLowPriority},
+			{Metrics{Load: 2}, StateRun},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.action), func(t *testing.T) {
+			source := &fakeSource{}
+			runSteps(t, New(source, Limits{Load: 4}, tt.action, time.Second, 0), source, tt.steps)
+		})
+	}
+}
+
+func TestSampleErrorKeepsState(t *testing.T) {
+	source := &fakeSource{metrics: Metrics{Load: 6}}
+	th := New(source, Limits{Load: 4}, ActionPause, time.Second, 0)
+	th.Check()
+
+	source.err = errors.New("no /proc")
+	state, reason, err := th.Check()
+	if err == nil || state != StatePause || reason == "" {
+		t.Errorf("Check() = %v, %q, %v; want the previous pause and the error", state, reason, err)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	th, err := FromConfig(config.ThrottleConfig{})
+	if th != nil || err != nil {
+		t.Errorf("no limits = %v, %v; want nil", th, err)
+	}
+
+	th, err = FromConfig(config.ThrottleConfig{MaxLoad: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th.action != ActionPause || th.Interval() != 10*time.Second || th.dwell != 5*time.Minute {
+		t.Errorf("defaults = %s, %s, %s", th.action, th.Interval(), th.dwell)
+	}
+
+	// Checked even without limits, so a typo doesn't wait for a limit to surface
+	for _, cfg := range []config.ThrottleConfig{
+		{MaxLoad: 4, Action: "stop"},
+		{Action: "stop"},
+		{MaxLoad: -1},
+		{MaxLoad: 4, MinDwell: -1},
+	} {
+		if _, err := FromConfig(cfg); err == nil {
+			t.Errorf("FromConfig(%+v) accepted an invalid config", cfg)
+		}
+	}
+}
+
+func TestSystemSource(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, data string) {
+		path = filepath.Join(dir, path)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(data), 0644)
+	}
+	write("proc/loadavg", "3.52 2.10 1.05 2/345 6789\n")
+	write("proc/pressure/cpu", "some avg10=12.50 avg60=8.00 avg300=2.00 total=123\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n")
+	write("sys/class/thermal/thermal_zone0/temp", "45000\n")
+	write("sys/class/thermal/thermal_zone1/temp", "71500\n")
+
+	source := &SystemSource{procDir: filepath.Join(dir, "proc"), sysDir: filepath.Join(dir, "sys")}
+	m, err := source.Sample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Metrics{Load: 3.52, CPUPressure: 12.5, Temperature: 71.5}); m != want {
+		t.Errorf("Sample() = %+v, want %+v", m, want)
+	}
+
+	// Only the load average is required
+	os.RemoveAll(filepath.Join(dir, "sys"))
+	os.RemoveAll(filepath.Join(dir, "proc", "pressure"))
+	if m, err := source.Sample(); err != nil || m.CPUPressure != 0 || m.Temperature != 0 {
+		t.Errorf("Sample() without PSI and thermal zones = %+v, %v", m, err)
+	}
+
+	os.Remove(filepath.Join(dir, "proc", "loadavg"))
+	if _, err := source.Sample(); err == nil || !strings.Contains(err.Error(), "load average") {
+		t.Errorf("Sample() without loadavg = %v, want an error", err)
+	}
+}
+
+func TestDwell(t *testing.T) {
+	source := &fakeSource{}
+	th := New(source, Limits{Load: 4}, ActionPause, time.Second, 5*time.Minute)
+	clock := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	th.now = func() time.Time { return clock }
+
+	steps := []struct {
+		after   time.Duration
+		metrics Metrics
+		want    State
+	}{
+		{0, Metrics{Load: 6}, StatePause},               // First change is immediate
+		{time.Minute, Metrics{Load: 1}, StatePause},     // Paused encodes cooled the machine, too soon
+		{2 * time.Minute, Metrics{Load: 7}, StatePause}, // Still paused, reason follows the metrics
+		{3 * time.Minute, Metrics{Load: 1}, StateRun},   // 6 minutes after pausing
+		{time.Minute, Metrics{Load: 6}, StateRun},       // Resumed encodes raise the load, too soon
+		{4 * time.Minute, Metrics{Load: 6}, StatePause}, // 5 minutes after resuming
+	}
+	for i, s := range steps {
+		clock = clock.Add(s.after)
+		source.metrics = s.metrics
+		state, reason, _ := th.Check()
+		if state != s.want {
+			t.Fatalf("step %d: state = %v (%q), want %v", i, state, reason, s.want)
+		}
+		if i == 2 && !strings.Contains(reason, "load 7.0") {
+			t.Errorf("step %d: reason %q not updated", i, reason)
+		}
+	}
+}
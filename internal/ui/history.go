@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// historyLimit is how many rips the history view loads
+const historyLimit = 50
+
+// loadHistory reads the rips matching the current query
+func (m *Model) loadHistory() {
+	if m.history == nil {
+		return
+	}
+	m.historyRecords, m.historyErr = m.history.Search(m.historyQuery, historyLimit)
+}
+
+// handleHistorySearchKey edits the history search query
+// Enter runs the search, Esc clears it
+func (m *Model) handleHistorySearchKey(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.historySearching = false
+		m.loadHistory()
+	case tea.KeyEsc:
+		m.historySearching = false
+		m.historyQuery = ""
+		m.loadHistory()
+	case tea.KeyBackspace:
+		if r := []rune(m.historyQuery); len(r) > 0 {
+			m.historyQuery = string(r[:len(r)-1])
+		}
+	case tea.KeySpace:
+		m.historyQuery += " "
+	case tea.KeyRunes:
+		m.historyQuery += string(msg.Runes)
+	}
+}
+
+func (m Model) renderHistorySection() string {
+	title := lipgloss.NewStyle().Bold(true).Render("RIP HISTORY")
+
+	var lines []string
+	heading := fmt.Sprintf("%s (%d rips)", title, len(m.historyRecords))
+	if m.historySearching {
+		heading += fmt.Sprintf("  Search: %s█", m.historyQuery)
+	} else if m.historyQuery != "" {
+		heading += fmt.Sprintf("  Search: %s", m.historyQuery)
+	}
+	lines = append(lines, heading)
+
+	if m.historyErr != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		lines = append(lines, errorStyle.Render(fmt.Sprintf("Error: %v", m.historyErr)))
+		return strings.Join(lines, "\n")
+	}
+	if len(m.historyRecords) == 0 {
+		if m.historyQuery != "" {
+			lines = append(lines, "No rips match the search")
+		} else {
+			lines = append(lines, "No discs ripped yet")
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	for _, rec := range m.historyRecords {
+		line := fmt.Sprintf("%s  %s (%s)  %d/%d titles  %s",
+			rec.StartedAt.Format("2006-01-02 15:04"), rec.Folder, rec.DiscType,
+			rec.Ripped(), len(rec.Titles), rec.Status)
+		if size := rec.RawSize(); size > 0 {
+			line += "  " + formatSize(size)
+			if encoded := rec.EncodedSize(); encoded > 0 {
+				line += " → " + formatSize(encoded)
+			}
+		}
+		if r := []rune(line); len(r) > m.width-2 && m.width > 10 {
+			line = string(r[:m.width-5]) + "..."
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -8,7 +8,10 @@ import (
 	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mmzim/mkvauto/internal/disk"
 	"github.com/mmzim/mkvauto/internal/encode"
+	"github.com/mmzim/mkvauto/internal/history"
+	"github.com/mmzim/mkvauto/internal/series"
 )
 
 type RipState int
@@ -17,11 +20,36 @@ const (
 	StateWaiting RipState = iota
 	StateScanning
 	StateSelectingTitles
+	StateConfirmingMetadata
+	StateConfirmingRerip
 	StateRipping
 	StateComplete
 	StateError
 )
 
+func (s RipState) String() string {
+	switch s {
+	case StateWaiting:
+		return "waiting"
+	case StateScanning:
+		return "scanning"
+	case StateSelectingTitles:
+		return "selecting_titles"
+	case StateConfirmingMetadata:
+		return "confirming_metadata"
+	case StateConfirmingRerip:
+		return "confirming_rerip"
+	case StateRipping:
+		return "ripping"
+	case StateComplete:
+		return "complete"
+	case StateError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
 type DiskInfo struct {
 	Name     string
 	DiscType string
@@ -33,29 +61,91 @@ type Title struct {
 	Name     string
 	Duration string
 	Size     string
+	Info     string // Source playlist, resolution and audio languages
 	Selected bool
 }
 
+// MetadataMatch is a database match offered for confirmation
+type MetadataMatch struct {
+	Name     string // e.g. "Star Wars (1977)"
+	Runtime  string
+	Overview string
+}
+
+// PreviousRip summarizes an earlier rip of the inserted disc
+type PreviousRip struct {
+	StartedAt time.Time
+	Folder    string
+	Ripped    int // Titles ripped
+	Titles    int // Titles selected
+	Status    string
+}
+
+// DriveControl wires a drive's rip panel to its pipeline in the app
+type DriveControl struct {
+	Device           string
+	Name             string
+	TitleSelectionCh chan<- []int
+	MetadataCh       chan<- int // Index of the confirmed match, -1 to keep the disc label
+	CancelRipCh      chan<- struct{}
+	PauseRipCh       chan<- bool // true pauses the rip, false resumes it
+	KnownDiscCh      chan<- bool // true rips a previously ripped disc again, false ejects it
+	TrayCh           chan<- bool // true opens the tray, false closes it
+}
+
 // Messages for bubbletea
-type DiskInsertedMsg struct{}
+// Rip messages carry the device path of the drive they belong to
+type DiskInsertedMsg struct {
+	Drive string
+}
 type ScanCompleteMsg struct {
-	Info DiskInfo
+	Drive string
+	Info  DiskInfo
+}
+type MediaChangedMsg struct {
+	Drive string
+	State disk.State
+	Err   error // Why the drive can't be read
 }
 type StatusUpdateMsg struct {
+	Drive  string
 	Status string
 }
 type ShowTitleSelectionMsg struct {
-	Titles []Title
+	Drive   string
+	Titles  []Title
+	Auto    bool          // Selected titles are the automatic selection, offered for confirmation
+	Timeout time.Duration // Confirm the automatic selection after this long without input (0 = wait)
+}
+type ShowKnownDiscMsg struct {
+	Drive string
+	Rips  []PreviousRip // Newest first
+}
+type ShowMetadataMatchMsg struct {
+	Drive   string
+	Query   string
+	Matches []MetadataMatch
 }
 type TitlesSelectedMsg struct {
+	Drive       string
 	SelectedIDs []int
 }
 type RipProgressMsg struct {
+	Drive        string
 	Progress     float64
 	CurrentTitle int
 	TotalTitles  int
 }
-type RipCompleteMsg struct{}
+type RipCompleteMsg struct {
+	Drive string
+}
+type RipCancelledMsg struct {
+	Drive string
+}
+type RipPausedMsg struct {
+	Drive  string
+	Paused bool
+}
 type EncodeProgressMsg struct {
 	ItemID   string
 	Progress float64
@@ -63,47 +153,115 @@ type EncodeProgressMsg struct {
 type EncodeCompleteMsg struct {
 	ItemID string
 }
+
+// EncodePausedMsg reports an encode paused or resumed outside the TUI,
+// through the control API or CLI
+type EncodePausedMsg struct {
+	ItemID string // Empty for every running encode
+	Paused bool
+}
+type EncodeHeldMsg struct {
+	Reason   string // Why new encodes are held back, "" when they run
+	Next     string // When the encode window opens or closes next
+	Throttle string // Why encodes run at lowered priority, "" when they don't
+}
 type QueueUpdateMsg struct{}
 type ErrorMsg struct {
-	Err error
+	Drive string
+	Err   error
 }
 type LogMsg struct {
-	Line string
+	Line   string
+	Output bool // Raw makemkvcon or HandBrake output rather than a message of mkvauto's
+}
+type CancelAndEjectMsg struct {
+	Drive string
 }
-type CancelAndEjectMsg struct{}
 type ScanForMissingMsg struct{}
 
-type Model struct {
-	// Ripping state
-	ripState      RipState
-	ripStatus     string // Current operation status (e.g., "Opening disc...", "Processing titles...")
-	diskInfo      DiskInfo
-	ripProgress   float64
-	currentTitle  int
-	totalTitles   int
-	ripPaused     bool
-	ripStartTime  time.Time
-	ripETA        string
+// selectionTickMsg drives the countdown of an automatic title selection
+type selectionTickMsg struct {
+	Drive string
+}
 
-	// Title selection
-	availableTitles []Title
-	selectedCursor  int
+// drivePanel holds the rip state of a single drive
+type drivePanel struct {
+	device           string
+	name             string
 	titleSelectionCh chan<- []int
+	metadataCh       chan<- int
+	cancelRipCh      chan<- struct{}
+	pauseRipCh       chan<- bool
+	knownDiscCh      chan<- bool
+	trayCh           chan<- bool
+
+	media        disk.State // Media state reported by the drive
+	mediaErr     error      // Why the drive can't be read
+	ripState     RipState
+	ripStatus    string // Current operation status (e.g., "Opening disc...", "Processing titles...")
+	diskInfo     DiskInfo
+	ripProgress  float64
+	currentTitle int
+	totalTitles  int
+	ripPaused    bool
+	ripPausedAt  time.Time
+	ripStartTime time.Time
+	ripETA       string
+
+	// Title selection
+	availableTitles   []Title
+	selectedCursor    int
+	selectionAuto     bool      // Checked titles came from the automatic selection
+	selectionDeadline time.Time // When the automatic selection proceeds (zero = no countdown)
+
+	// Previously ripped disc confirmation
+	previousRips []PreviousRip
+
+	// Metadata confirmation
+	metadataQuery   string
+	metadataMatches []MetadataMatch
+	metadataCursor  int
+
+	// Error
+	err error
+}
+
+// busy reports whether the drive has a disc being processed
+func (p *drivePanel) busy() bool {
+	return p.ripState == StateScanning || p.ripState == StateRipping || p.ripState == StateSelectingTitles ||
+		p.ripState == StateConfirmingMetadata || p.ripState == StateConfirmingRerip
+}
+
+// waitingForUser reports whether the drive is blocked on a prompt
+func (p *drivePanel) waitingForUser() bool {
+	return p.ripState == StateSelectingTitles || p.ripState == StateConfirmingMetadata || p.ripState == StateConfirmingRerip
+}
+
+type Model struct {
+	// Ripping state, one panel per drive
+	drives      []*drivePanel
+	activeDrive int
+
+	// Active TV series session, if any
+	series *series.Store
 
 	// Encoding state
 	encodeQueue      *encode.Queue
-	currentEncode    *encode.QueueItem
-	encodePaused     bool
-	encodeStartTime  time.Time
-	encodeETA        string
+	queueCursor      int                  // Selected running or queued item
+	encodePaused     map[string]bool      // Paused encodes by item ID
+	encodeStartTimes map[string]time.Time // When each encode started reporting progress
+	encodeStartPct   map[string]float64   // Progress at that time, resumed encodes don't start at 0
+	encodeETAs       map[string]string
+	encodeHold       string // Why new encodes are held back
+	encodeNext       string // When the encode window changes next
+	encodeThrottle   string // Why encodes run at lowered priority
 
 	// UI components
 	ripProgressBar    progress.Model
 	encodeProgressBar progress.Model
 
 	// Controls
-	workerControl chan encode.WorkerControl
-	cancelRipCh   chan<- struct{}
+	workerControl chan encode.ItemControl
 	scanRequestCh chan<- struct{}
 
 	// Logs
@@ -111,24 +269,48 @@ type Model struct {
 	logLines []string
 	maxLogs  int
 
+	// Rip history
+	history          *history.Store
+	showHistory      bool
+	historySearching bool // Typing a search query
+	historyQuery     string
+	historyRecords   []*history.Record
+	historyErr       error
+
 	// Config
 	outputDir string
 
-	// Error
-	err error
-
 	// Window size
 	width  int
 	height int
 }
 
-func NewModel(queue *encode.Queue, workerControl chan encode.WorkerControl, titleSelectionCh chan<- []int, outputDir string, cancelRipCh chan<- struct{}, scanRequestCh chan<- struct{}) Model {
+func NewModel(queue *encode.Queue, sessions *series.Store, ripHistory *history.Store, workerControl chan encode.ItemControl, drives []DriveControl, outputDir string, scanRequestCh chan<- struct{}) Model {
+	panels := make([]*drivePanel, len(drives))
+	for i, d := range drives {
+		panels[i] = &drivePanel{
+			device:           d.Device,
+			name:             d.Name,
+			titleSelectionCh: d.TitleSelectionCh,
+			metadataCh:       d.MetadataCh,
+			cancelRipCh:      d.CancelRipCh,
+			pauseRipCh:       d.PauseRipCh,
+			knownDiscCh:      d.KnownDiscCh,
+			trayCh:           d.TrayCh,
+			ripState:         StateWaiting,
+		}
+	}
+
 	return Model{
-		ripState:          StateWaiting,
+		drives:            panels,
+		series:            sessions,
+		history:           ripHistory,
 		encodeQueue:       queue,
+		encodePaused:      make(map[string]bool),
+		encodeStartTimes:  make(map[string]time.Time),
+		encodeStartPct:    make(map[string]float64),
+		encodeETAs:        make(map[string]string),
 		workerControl:     workerControl,
-		titleSelectionCh:  titleSelectionCh,
-		cancelRipCh:       cancelRipCh,
 		scanRequestCh:     scanRequestCh,
 		ripProgressBar:    progress.New(progress.WithDefaultGradient()),
 		encodeProgressBar: progress.New(progress.WithDefaultGradient()),
@@ -141,6 +323,34 @@ func NewModel(queue *encode.Queue, workerControl chan encode.WorkerControl, titl
 	}
 }
 
+// drive returns the panel for a device path, or nil if unknown
+func (m Model) drive(device string) *drivePanel {
+	for _, p := range m.drives {
+		if p.device == device {
+			return p
+		}
+	}
+	return nil
+}
+
+// focusedDrive returns the panel that receives rip key presses
+// A drive waiting for title selection or metadata confirmation always takes focus
+func (m *Model) focusedDrive() *drivePanel {
+	if len(m.drives) == 0 {
+		return nil
+	}
+	if m.drives[m.activeDrive].waitingForUser() {
+		return m.drives[m.activeDrive]
+	}
+	for i, p := range m.drives {
+		if p.waitingForUser() {
+			m.activeDrive = i
+			return p
+		}
+	}
+	return m.drives[m.activeDrive]
+}
+
 func (m Model) Init() tea.Cmd {
 	return nil
 }
@@ -156,39 +366,102 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case DiskInsertedMsg:
-		m.ripState = StateScanning
-		m.ripStatus = "Initializing scan..."
+		if d := m.drive(msg.Drive); d != nil {
+			d.ripState = StateScanning
+			d.ripStatus = "Initializing scan..."
+			d.ripProgress = 0
+			d.ripETA = ""
+			d.ripPaused = false
+			d.err = nil
+		}
+		return m, nil
+
+	case MediaChangedMsg:
+		if d := m.drive(msg.Drive); d != nil {
+			d.media = msg.State
+			d.mediaErr = msg.Err
+		}
 		return m, nil
 
 	case StatusUpdateMsg:
-		m.ripStatus = msg.Status
+		if d := m.drive(msg.Drive); d != nil {
+			d.ripStatus = msg.Status
+		}
 		return m, nil
 
 	case ScanCompleteMsg:
-		m.diskInfo = msg.Info
-		m.ripState = StateRipping
-		m.ripStatus = ""
+		if d := m.drive(msg.Drive); d != nil {
+			d.diskInfo = msg.Info
+			d.ripState = StateRipping
+			d.ripStatus = ""
+		}
 		return m, nil
 
 	case ShowTitleSelectionMsg:
-		m.availableTitles = msg.Titles
-		m.selectedCursor = 0
-		m.ripState = StateSelectingTitles
+		if d := m.drive(msg.Drive); d != nil {
+			d.availableTitles = msg.Titles
+			d.selectedCursor = 0
+			d.selectionAuto = msg.Auto
+			d.selectionDeadline = time.Time{}
+			d.ripState = StateSelectingTitles
+			if msg.Auto && msg.Timeout > 0 {
+				d.selectionDeadline = time.Now().Add(msg.Timeout)
+				return m, selectionTick(d.device)
+			}
+		}
+		return m, nil
+
+	case selectionTickMsg:
+		d := m.drive(msg.Drive)
+		if d == nil || d.ripState != StateSelectingTitles || d.selectionDeadline.IsZero() {
+			return m, nil
+		}
+		if time.Now().Before(d.selectionDeadline) {
+			return m, selectionTick(d.device)
+		}
+
+		// Nobody objected, go ahead with the automatic selection
+		d.selectionDeadline = time.Time{}
+		d.confirmSelection()
+		return m, nil
+
+	case ShowKnownDiscMsg:
+		if d := m.drive(msg.Drive); d != nil {
+			d.previousRips = msg.Rips
+			d.ripState = StateConfirmingRerip
+		}
+		return m, nil
+
+	case ShowMetadataMatchMsg:
+		if d := m.drive(msg.Drive); d != nil {
+			d.metadataQuery = msg.Query
+			d.metadataMatches = msg.Matches
+			d.metadataCursor = 0
+			d.ripState = StateConfirmingMetadata
+		}
 		return m, nil
 
 	case RipProgressMsg:
+		d := m.drive(msg.Drive)
+		if d == nil {
+			return m, nil
+		}
+
 		// Initialize start time if this is the first progress update
-		if m.ripProgress == 0 && msg.Progress > 0 {
-			m.ripStartTime = time.Now()
+		if d.ripProgress == 0 && msg.Progress > 0 {
+			d.ripStartTime = time.Now()
 		}
 
-		m.ripProgress = msg.Progress
-		m.currentTitle = msg.CurrentTitle
-		m.totalTitles = msg.TotalTitles
+		d.ripProgress = msg.Progress
+		d.currentTitle = msg.CurrentTitle
+		d.totalTitles = msg.TotalTitles
 
-		// Calculate ETA
+		// Calculate ETA, frozen while paused
+		if d.ripPaused {
+			return m, nil
+		}
 		if msg.Progress > 0 && msg.Progress < 100 {
-			elapsed := time.Since(m.ripStartTime).Seconds()
+			elapsed := time.Since(d.ripStartTime).Seconds()
 			totalEstimated := elapsed / (msg.Progress / 100.0)
 			remaining := totalEstimated - elapsed
 
@@ -199,39 +472,68 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				seconds := int(remainingDuration.Seconds()) % 60
 
 				if hours > 0 {
-					m.ripETA = fmt.Sprintf("%dh %dm %ds", hours, minutes, seconds)
+					d.ripETA = fmt.Sprintf("%dh %dm %ds", hours, minutes, seconds)
 				} else if minutes > 0 {
-					m.ripETA = fmt.Sprintf("%dm %ds", minutes, seconds)
+					d.ripETA = fmt.Sprintf("%dm %ds", minutes, seconds)
 				} else {
-					m.ripETA = fmt.Sprintf("%ds", seconds)
+					d.ripETA = fmt.Sprintf("%ds", seconds)
 				}
 			}
 		} else if msg.Progress >= 100 {
-			m.ripETA = "Complete"
+			d.ripETA = "Complete"
 		}
 
 		return m, nil
 
 	case RipCompleteMsg:
-		m.ripState = StateComplete
-		m.ripProgress = 100.0
+		if d := m.drive(msg.Drive); d != nil {
+			d.ripState = StateComplete
+			d.ripProgress = 100.0
+			d.ripPaused = false
+		}
+		return m, nil
+
+	case RipPausedMsg:
+		d := m.drive(msg.Drive)
+		if d == nil || d.ripPaused == msg.Paused {
+			return m, nil
+		}
+		d.ripPaused = msg.Paused
+		if msg.Paused {
+			d.ripPausedAt = time.Now()
+		} else if !d.ripStartTime.IsZero() {
+			// Leave the paused time out of the ETA
+			d.ripStartTime = d.ripStartTime.Add(time.Since(d.ripPausedAt))
+		}
+		return m, nil
+
+	case RipCancelledMsg:
+		// Cancelled from outside the TUI (e.g. the control API)
+		if d := m.drive(msg.Drive); d != nil {
+			d.ripState = StateWaiting
+			d.ripStatus = ""
+			d.ripProgress = 0
+			d.currentTitle = 0
+			d.totalTitles = 0
+			d.ripETA = ""
+			d.ripPaused = false
+		}
 		return m, nil
 
 	case EncodeProgressMsg:
 		// Initialize start time if this is the first progress update
-		if m.currentEncode == nil || m.currentEncode.Progress == 0 && msg.Progress > 0 {
-			m.encodeStartTime = time.Now()
+		if _, ok := m.encodeStartTimes[msg.ItemID]; !ok || msg.Progress == 0 {
+			m.encodeStartTimes[msg.ItemID] = time.Now()
+			m.encodeStartPct[msg.ItemID] = msg.Progress
 		}
 
 		// Update progress in queue
 		m.encodeQueue.UpdateProgress(msg.ItemID, msg.Progress)
-		m.currentEncode = m.encodeQueue.GetCurrent()
 
 		// Calculate ETA
-		if m.currentEncode != nil && msg.Progress > 0 && msg.Progress < 100 {
-			elapsed := time.Since(m.encodeStartTime).Seconds()
-			totalEstimated := elapsed / (msg.Progress / 100.0)
-			remaining := totalEstimated - elapsed
+		if start := m.encodeStartPct[msg.ItemID]; msg.Progress > start && msg.Progress < 100 {
+			elapsed := time.Since(m.encodeStartTimes[msg.ItemID]).Seconds()
+			remaining := elapsed / (msg.Progress - start) * (100 - msg.Progress)
 
 			if remaining > 0 {
 				remainingDuration := time.Duration(remaining) * time.Second
@@ -240,26 +542,54 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				seconds := int(remainingDuration.Seconds()) % 60
 
 				if hours > 0 {
-					m.encodeETA = fmt.Sprintf("%dh %dm %ds", hours, minutes, seconds)
+					m.encodeETAs[msg.ItemID] = fmt.Sprintf("%dh %dm %ds", hours, minutes, seconds)
 				} else if minutes > 0 {
-					m.encodeETA = fmt.Sprintf("%dm %ds", minutes, seconds)
+					m.encodeETAs[msg.ItemID] = fmt.Sprintf("%dm %ds", minutes, seconds)
 				} else {
-					m.encodeETA = fmt.Sprintf("%ds", seconds)
+					m.encodeETAs[msg.ItemID] = fmt.Sprintf("%ds", seconds)
 				}
 			}
-		} else if m.currentEncode != nil && msg.Progress >= 100 {
-			m.encodeETA = "Complete"
+		} else if msg.Progress >= 100 {
+			m.encodeETAs[msg.ItemID] = "Complete"
 		}
 
 		return m, nil
 
+	case EncodeHeldMsg:
+		m.encodeHold = msg.Reason
+		m.encodeNext = msg.Next
+		m.encodeThrottle = msg.Throttle
+		return m, nil
+
+	case EncodePausedMsg:
+		ids := []string{msg.ItemID}
+		if msg.ItemID == "" {
+			ids = nil
+			for _, item := range m.encodeQueue.GetActive() {
+				ids = append(ids, item.ID)
+			}
+		}
+		for _, id := range ids {
+			if msg.Paused {
+				m.encodePaused[id] = true
+			} else {
+				delete(m.encodePaused, id)
+			}
+		}
+		return m, nil
+
 	case EncodeCompleteMsg:
-		m.currentEncode = nil
+		delete(m.encodePaused, msg.ItemID)
+		delete(m.encodeStartTimes, msg.ItemID)
+		delete(m.encodeStartPct, msg.ItemID)
+		delete(m.encodeETAs, msg.ItemID)
 		return m, nil
 
 	case ErrorMsg:
-		m.err = msg.Err
-		m.ripState = StateError
+		if d := m.drive(msg.Drive); d != nil {
+			d.err = msg.Err
+			d.ripState = StateError
+		}
 		return m, nil
 
 	case QueueUpdateMsg:
@@ -276,19 +606,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case CancelAndEjectMsg:
+		d := m.drive(msg.Drive)
+		if d == nil {
+			return m, nil
+		}
+
 		// Send cancel signal
 		select {
-		case m.cancelRipCh <- struct{}{}:
+		case d.cancelRipCh <- struct{}{}:
 		default:
 		}
 
 		// Reset to waiting state
-		m.ripState = StateWaiting
-		m.ripStatus = ""
-		m.ripProgress = 0
-		m.currentTitle = 0
-		m.totalTitles = 0
-		m.ripETA = ""
+		d.ripState = StateWaiting
+		d.ripStatus = ""
+		d.ripProgress = 0
+		d.currentTitle = 0
+		d.totalTitles = 0
+		d.ripETA = ""
+		d.ripPaused = false
 		return m, nil
 
 	case ScanForMissingMsg:
@@ -303,58 +639,178 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// controlEncode sends a control command to the encode workers outside of
+// Update, so a busy pool can't freeze the TUI
+func (m Model) controlEncode(itemID string, ctrl encode.WorkerControl) tea.Cmd {
+	ch := m.workerControl
+	return func() tea.Msg {
+		ch <- encode.ItemControl{ItemID: itemID, Control: ctrl}
+		return nil
+	}
+}
+
+// selectionTick schedules the next countdown update for a drive's title selection
+func selectionTick(device string) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return selectionTickMsg{Drive: device}
+	})
+}
+
+// confirmSelection sends the checked titles to the app, if any are checked
+func (p *drivePanel) confirmSelection() {
+	var selectedIDs []int
+	for _, title := range p.availableTitles {
+		if title.Selected {
+			selectedIDs = append(selectedIDs, title.ID)
+		}
+	}
+
+	if len(selectedIDs) > 0 {
+		// Send selected titles back to app; it drops stale answers before asking
+		select {
+		case p.titleSelectionCh <- selectedIDs:
+		default:
+		}
+		p.ripState = StateRipping
+	}
+}
+
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Typing a history search takes every key until it's done
+	if m.historySearching {
+		m.handleHistorySearchKey(msg)
+		return m, nil
+	}
+
+	d := m.focusedDrive()
+
 	// Handle title selection mode
-	if m.ripState == StateSelectingTitles {
+	if d != nil && d.ripState == StateSelectingTitles {
+		// Any key means someone is looking, so stop the countdown
+		d.selectionDeadline = time.Time{}
+
 		switch msg.String() {
 		case "q", "ctrl+c":
 			return m, tea.Quit
 
 		case "up", "k":
-			if m.selectedCursor > 0 {
-				m.selectedCursor--
+			if d.selectedCursor > 0 {
+				d.selectedCursor--
 			}
 			return m, nil
 
 		case "down", "j":
-			if m.selectedCursor < len(m.availableTitles)-1 {
-				m.selectedCursor++
+			if d.selectedCursor < len(d.availableTitles)-1 {
+				d.selectedCursor++
 			}
 			return m, nil
 
 		case " ": // Space - toggle selection
-			if m.selectedCursor < len(m.availableTitles) {
-				m.availableTitles[m.selectedCursor].Selected = !m.availableTitles[m.selectedCursor].Selected
+			if d.selectedCursor < len(d.availableTitles) {
+				d.availableTitles[d.selectedCursor].Selected = !d.availableTitles[d.selectedCursor].Selected
 			}
 			return m, nil
 
 		case "a": // Select all
-			for i := range m.availableTitles {
-				m.availableTitles[i].Selected = true
+			for i := range d.availableTitles {
+				d.availableTitles[i].Selected = true
 			}
 			return m, nil
 
 		case "n": // Select none
-			for i := range m.availableTitles {
-				m.availableTitles[i].Selected = false
+			for i := range d.availableTitles {
+				d.availableTitles[i].Selected = false
 			}
 			return m, nil
 
 		case "enter":
-			// Confirm selection
-			var selectedIDs []int
-			for _, title := range m.availableTitles {
-				if title.Selected {
-					selectedIDs = append(selectedIDs, title.ID)
-				}
+			d.confirmSelection()
+			return m, nil
+
+		case "x", "e":
+			// Cancel and eject is still available while selecting
+			device := d.device
+			return m, func() tea.Msg {
+				return CancelAndEjectMsg{Drive: device}
+			}
+		}
+		return m, nil
+	}
+
+	// Handle previously ripped disc confirmation
+	if d != nil && d.ripState == StateConfirmingRerip {
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+
+		case "enter", "y":
+			// Rip it again
+			select {
+			case d.knownDiscCh <- true:
+			default:
 			}
+			d.ripState = StateRipping
+			return m, nil
 
-			if len(selectedIDs) > 0 {
-				// Send selected titles back to app
-				m.titleSelectionCh <- selectedIDs
-				m.ripState = StateRipping
+		case "n":
+			// Skip the disc, the app ejects it
+			select {
+			case d.knownDiscCh <- false:
+			default:
 			}
+			d.ripState = StateWaiting
 			return m, nil
+
+		case "x", "e":
+			device := d.device
+			return m, func() tea.Msg {
+				return CancelAndEjectMsg{Drive: device}
+			}
+		}
+		return m, nil
+	}
+
+	// Handle metadata confirmation mode
+	if d != nil && d.ripState == StateConfirmingMetadata {
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+
+		case "up", "k":
+			if d.metadataCursor > 0 {
+				d.metadataCursor--
+			}
+			return m, nil
+
+		case "down", "j":
+			if d.metadataCursor < len(d.metadataMatches)-1 {
+				d.metadataCursor++
+			}
+			return m, nil
+
+		case "enter":
+			// Use the highlighted match
+			select {
+			case d.metadataCh <- d.metadataCursor:
+			default:
+			}
+			d.ripState = StateRipping
+			return m, nil
+
+		case "s":
+			// Skip, keep the disc label
+			select {
+			case d.metadataCh <- -1:
+			default:
+			}
+			d.ripState = StateRipping
+			return m, nil
+
+		case "x", "e":
+			device := d.device
+			return m, func() tea.Msg {
+				return CancelAndEjectMsg{Drive: device}
+			}
 		}
 		return m, nil
 	}
@@ -364,51 +820,120 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "q", "ctrl+c":
 		return m, tea.Quit
 
+	case "tab":
+		// Cycle focus between drives
+		if len(m.drives) > 1 {
+			m.activeDrive = (m.activeDrive + 1) % len(m.drives)
+		}
+		return m, nil
+
 	case "x", "e":
-		// Cancel current operation and eject disc
-		if m.ripState == StateScanning || m.ripState == StateRipping || m.ripState == StateSelectingTitles {
+		// Cancel current operation on the focused drive and eject disc
+		if d != nil && d.busy() {
+			device := d.device
 			return m, func() tea.Msg {
-				return CancelAndEjectMsg{}
+				return CancelAndEjectMsg{Drive: device}
 			}
 		}
 		return m, nil
 
-	case "p":
-		// Pause ripping
-		m.ripPaused = true
+	case "p", "r":
+		// Pause or resume ripping; the panel updates once the app confirms
+		if d != nil && d.ripState == StateRipping && d.pauseRipCh != nil {
+			select {
+			case d.pauseRipCh <- msg.String() == "p":
+			default:
+			}
+		}
 		return m, nil
 
-	case "r":
-		// Resume ripping
-		m.ripPaused = false
+	case "o":
+		// Close an open tray, or open it while the drive is idle
+		if d != nil && !d.busy() && d.trayCh != nil {
+			select {
+			case d.trayCh <- d.media != disk.StateTrayOpen:
+			default:
+			}
+		}
+		return m, nil
+
+	case "up", "k":
+		// Select the previous running or queued item
+		if m.queueCursor > 0 {
+			m.queueCursor = max(min(m.queueCursor, len(m.selectableItems()))-1, 0)
+		}
+		return m, nil
+
+	case "down", "j":
+		// Select the next running or queued item
+		if m.queueCursor < len(m.selectableItems())-1 {
+			m.queueCursor++
+		}
+		return m, nil
+
+	case "K", "shift+up":
+		// Encode the selected queued item sooner
+		if item := m.selectedQueued(); item != nil {
+			m.encodeQueue.MoveUp(item.ID)
+			m.selectItem(item.ID)
+		}
+		return m, nil
+
+	case "J", "shift+down":
+		// Encode the selected queued item later
+		if item := m.selectedQueued(); item != nil {
+			m.encodeQueue.MoveDown(item.ID)
+			m.selectItem(item.ID)
+		}
+		return m, nil
+
+	case "g":
+		// Encode the selected queued item next
+		if item := m.selectedQueued(); item != nil {
+			m.encodeQueue.MoveToTop(item.ID)
+			m.selectItem(item.ID)
+		}
+		return m, nil
+
+	case "+", "=", "-":
+		// Raise or lower the priority of the selected queued item
+		if item := m.selectedQueued(); item != nil {
+			priority := item.Priority + 1
+			if msg.String() == "-" {
+				priority = item.Priority - 1
+			}
+			m.encodeQueue.SetPriority(item.ID, priority)
+			m.selectItem(item.ID)
+		}
 		return m, nil
 
 	case " ": // Space
-		// Toggle encode pause/resume
-		if m.currentEncode != nil {
-			if m.encodePaused {
-				m.workerControl <- encode.WorkerResume
-				m.encodePaused = false
-			} else {
-				m.workerControl <- encode.WorkerPause
-				m.encodePaused = true
+		// Toggle pause/resume of the selected encode
+		if item := m.selectedEncode(); item != nil {
+			if m.encodePaused[item.ID] {
+				delete(m.encodePaused, item.ID)
+				return m, m.controlEncode(item.ID, encode.WorkerResume)
 			}
+			m.encodePaused[item.ID] = true
+			return m, m.controlEncode(item.ID, encode.WorkerPause)
 		}
 		return m, nil
 
 	case "s":
-		// Stop/cancel current encode (keeps in queue as failed)
-		if m.currentEncode != nil {
-			m.workerControl <- encode.WorkerStop
-			m.encodePaused = false
+		// Stop/cancel the selected encode (keeps in queue as failed)
+		if item := m.selectedEncode(); item != nil {
+			delete(m.encodePaused, item.ID)
+			return m, m.controlEncode(item.ID, encode.WorkerStop)
 		}
 		return m, nil
 
 	case "d":
-		// Delete current encode (stop and remove from queue)
-		if m.currentEncode != nil {
-			m.workerControl <- encode.WorkerDelete
-			m.encodePaused = false
+		// Delete the selected encode (stop and remove from queue)
+		if item := m.selectedEncode(); item != nil {
+			delete(m.encodePaused, item.ID)
+			return m, m.controlEncode(item.ID, encode.WorkerDelete)
+		} else if item := m.selectedQueued(); item != nil {
+			m.encodeQueue.Remove(item.ID)
 		}
 		return m, nil
 
@@ -422,6 +947,21 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.showLogs = !m.showLogs
 		return m, nil
 
+	case "h":
+		// Toggle rip history view
+		m.showHistory = !m.showHistory
+		if m.showHistory {
+			m.loadHistory()
+		}
+		return m, nil
+
+	case "/":
+		// Search the rip history
+		if m.showHistory {
+			m.historySearching = true
+		}
+		return m, nil
+
 	case "t":
 		// Retry failed items
 		m.encodeQueue.RetryFailed()
@@ -453,8 +993,12 @@ func (m Model) View() string {
 	sections = append(sections, m.renderRippingSection())
 	sections = append(sections, strings.Repeat("─", m.width))
 
-	// Encoding section
-	sections = append(sections, m.renderEncodingSection())
+	// Encoding section, or the rip history in its place
+	if m.showHistory {
+		sections = append(sections, m.renderHistorySection())
+	} else {
+		sections = append(sections, m.renderEncodingSection())
+	}
 	sections = append(sections, strings.Repeat("─", m.width))
 
 	// Controls
@@ -473,45 +1017,97 @@ func (m Model) View() string {
 }
 
 func (m Model) renderRippingSection() string {
-	title := lipgloss.NewStyle().Bold(true).Render("RIPPING")
+	var panels []string
+	if session := m.series.Current(); session != nil {
+		seriesStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("86"))
+		panels = append(panels, seriesStyle.Render(fmt.Sprintf("Series: %s season %d, next disc starts at E%02d",
+			session.Show, session.Season, session.NextEpisode)))
+	}
+	for i, d := range m.drives {
+		panels = append(panels, m.renderDrivePanel(d, i == m.activeDrive))
+	}
+	return strings.Join(panels, "\n\n")
+}
+
+func (m Model) renderDrivePanel(d *drivePanel, active bool) string {
+	heading := "RIPPING"
+	if len(m.drives) > 1 {
+		marker := "  "
+		if active {
+			marker = "▶ "
+		}
+		heading = fmt.Sprintf("%sRIPPING %s", marker, d.name)
+		if d.name != d.device {
+			heading += fmt.Sprintf(" (%s)", d.device)
+		}
+	}
+	title := lipgloss.NewStyle().Bold(true).Render(heading)
 
 	var lines []string
 	lines = append(lines, title)
 
-	switch m.ripState {
+	switch d.ripState {
 	case StateWaiting:
-		lines = append(lines, "Waiting for disc insertion...")
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		switch d.media {
+		case disk.StateTrayOpen:
+			lines = append(lines, "Tray open, waiting for disc insertion...")
+		case disk.StateMissing:
+			lines = append(lines, errorStyle.Render(fmt.Sprintf("Drive not found: %v", d.mediaErr)))
+			lines = append(lines, "Waiting for the drive to come back...")
+		case disk.StateError:
+			lines = append(lines, errorStyle.Render(fmt.Sprintf("Cannot access drive: %v", d.mediaErr)))
+		case disk.StateNotReady:
+			lines = append(lines, "Disc inserted, waiting for the drive...")
+		case disk.StateDisc:
+			lines = append(lines, "Disc detected...")
+		default:
+			lines = append(lines, "Waiting for disc insertion...")
+		}
 
 	case StateScanning:
-		if m.ripStatus != "" {
-			lines = append(lines, m.ripStatus)
+		if d.ripStatus != "" {
+			lines = append(lines, d.ripStatus)
 		} else {
 			lines = append(lines, "Scanning disc, please wait...")
 		}
 
 	case StateSelectingTitles:
-		lines = append(lines, fmt.Sprintf("Disc: %s (%s)", m.diskInfo.Name, m.diskInfo.DiscType))
+		lines = append(lines, fmt.Sprintf("Disc: %s (%s)", d.diskInfo.Name, d.diskInfo.DiscType))
 		lines = append(lines, "")
-		lines = append(lines, "No titles matched automatic selection criteria.")
-		lines = append(lines, "Please select titles to rip:")
+		if d.selectionAuto {
+			lines = append(lines, "Automatically selected titles are checked.")
+			if !d.selectionDeadline.IsZero() {
+				remaining := time.Until(d.selectionDeadline).Round(time.Second)
+				lines = append(lines, fmt.Sprintf("Ripping them in %s, press any key to adjust:", remaining))
+			} else {
+				lines = append(lines, "Adjust the selection and confirm:")
+			}
+		} else {
+			lines = append(lines, "No titles matched automatic selection criteria.")
+			lines = append(lines, "Please select titles to rip:")
+		}
 		lines = append(lines, "")
 
 		// Show titles with selection checkboxes
-		for i, t := range m.availableTitles {
+		for i, t := range d.availableTitles {
 			checkbox := "[ ]"
 			if t.Selected {
 				checkbox = "[✓]"
 			}
 
 			cursor := "  "
-			if i == m.selectedCursor {
+			if i == d.selectedCursor {
 				cursor = "→ "
 			}
 
 			titleLine := fmt.Sprintf("%s%s Title %d: %s - %s (%s)",
 				cursor, checkbox, t.ID, t.Name, t.Duration, t.Size)
+			if t.Info != "" {
+				titleLine += "  " + t.Info
+			}
 
-			if i == m.selectedCursor {
+			if i == d.selectedCursor {
 				highlightStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 				titleLine = highlightStyle.Render(titleLine)
 			}
@@ -522,21 +1118,74 @@ func (m Model) renderRippingSection() string {
 		lines = append(lines, "")
 		lines = append(lines, "[↑↓] Navigate  [Space] Toggle  [A] Select All  [N] None  [Enter] Confirm")
 
+	case StateConfirmingRerip:
+		lines = append(lines, fmt.Sprintf("Disc: %s (%s)", d.diskInfo.Name, d.diskInfo.DiscType))
+		lines = append(lines, "")
+		lines = append(lines, "This disc was ripped before:")
+		for _, rip := range d.previousRips {
+			lines = append(lines, fmt.Sprintf("  %s  %s  %d of %d titles, %s",
+				rip.StartedAt.Format("2006-01-02 15:04"), rip.Folder, rip.Ripped, rip.Titles, rip.Status))
+		}
+		lines = append(lines, "")
+		lines = append(lines, "[Y/Enter] Rip Again  [N] Skip & Eject")
+
+	case StateConfirmingMetadata:
+		lines = append(lines, fmt.Sprintf("Disc: %s (%s)", d.diskInfo.Name, d.diskInfo.DiscType))
+		lines = append(lines, "")
+		lines = append(lines, fmt.Sprintf("Matches for %q:", d.metadataQuery))
+		lines = append(lines, "")
+
+		for i, match := range d.metadataMatches {
+			cursor := "  "
+			if i == d.metadataCursor {
+				cursor = "→ "
+			}
+
+			matchLine := cursor + match.Name
+			if match.Runtime != "" {
+				matchLine += fmt.Sprintf(" - %s", match.Runtime)
+			}
+
+			if i == d.metadataCursor {
+				highlightStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+				matchLine = highlightStyle.Render(matchLine)
+			}
+
+			lines = append(lines, matchLine)
+		}
+
+		if d.metadataCursor < len(d.metadataMatches) {
+			if overview := d.metadataMatches[d.metadataCursor].Overview; overview != "" {
+				if r := []rune(overview); len(r) > m.width-4 && m.width > 10 {
+					overview = string(r[:m.width-7]) + "..."
+				}
+				lines = append(lines, "")
+				lines = append(lines, lipgloss.NewStyle().Faint(true).Render(overview))
+			}
+		}
+
+		lines = append(lines, "")
+		lines = append(lines, "[↑↓] Navigate  [Enter] Use Match  [S] Skip (keep disc label)")
+
 	case StateRipping:
-		lines = append(lines, fmt.Sprintf("Disc: %s (%s)", m.diskInfo.Name, m.diskInfo.DiscType))
+		lines = append(lines, fmt.Sprintf("Disc: %s (%s)", d.diskInfo.Name, d.diskInfo.DiscType))
 		lines = append(lines, fmt.Sprintf("Output: %s", m.outputDir))
 
-		if m.ripStatus != "" {
-			lines = append(lines, fmt.Sprintf("Status: %s", m.ripStatus))
+		if d.ripStatus != "" {
+			lines = append(lines, fmt.Sprintf("Status: %s", d.ripStatus))
 		} else {
-			lines = append(lines, fmt.Sprintf("Status: Ripping title %d of %d (%.1f%%)", m.currentTitle, m.totalTitles, m.ripProgress))
+			lines = append(lines, fmt.Sprintf("Status: Ripping title %d of %d (%.1f%%)", d.currentTitle, d.totalTitles, d.ripProgress))
 		}
 
-		if m.ripETA != "" {
-			lines = append(lines, fmt.Sprintf("ETA: %s", m.ripETA))
+		if d.ripETA != "" {
+			eta := d.ripETA
+			if d.ripPaused {
+				eta += " (paused)"
+			}
+			lines = append(lines, fmt.Sprintf("ETA: %s", eta))
 		}
-		lines = append(lines, m.ripProgressBar.ViewAs(m.ripProgress/100.0))
-		if m.ripPaused {
+		lines = append(lines, m.ripProgressBar.ViewAs(d.ripProgress/100.0))
+		if d.ripPaused {
 			lines = append(lines, "[PAUSED] Press R to resume")
 		} else {
 			lines = append(lines, "[P] Pause  [R] Resume")
@@ -548,7 +1197,18 @@ func (m Model) renderRippingSection() string {
 
 	case StateError:
 		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
-		lines = append(lines, errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		lines = append(lines, errorStyle.Render(fmt.Sprintf("Error: %v", d.err)))
+	}
+
+	// The disc or the whole drive went away while it was still needed
+	if d.busy() {
+		warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+		switch d.media {
+		case disk.StateTrayOpen, disk.StateNoDisc:
+			lines = append(lines, warningStyle.Render("Warning: the disc was removed from the drive"))
+		case disk.StateMissing:
+			lines = append(lines, warningStyle.Render("Warning: the drive disappeared"))
+		}
 	}
 
 	return strings.Join(lines, "\n")
@@ -563,36 +1223,66 @@ func (m Model) renderEncodingSection() string {
 	var lines []string
 	lines = append(lines, fmt.Sprintf("%s (%d items)", title, queueSize))
 
-	// Only show current encode if it's actually still encoding
-	if m.currentEncode != nil && m.currentEncode.Status == encode.StatusEncoding {
-		lines = append(lines, fmt.Sprintf("▶ Current: %s (%s/AV1)", m.currentEncode.TitleName, m.currentEncode.DiscType))
-		if m.encodeETA != "" {
-			lines = append(lines, fmt.Sprintf("  ETA: %s", m.encodeETA))
+	// Held back by the encode schedule or system load
+	if m.encodeHold != "" {
+		holdStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+		line := "Held: " + m.encodeHold
+		if m.encodeNext != "" {
+			line += ", " + m.encodeNext
+		}
+		lines = append(lines, holdStyle.Render(line))
+	} else if m.encodeNext != "" {
+		lines = append(lines, lipgloss.NewStyle().Faint(true).Render(capitalize(m.encodeNext)))
+	}
+	if m.encodeThrottle != "" {
+		throttleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+		lines = append(lines, throttleStyle.Render("Lowered priority: "+m.encodeThrottle))
+	}
+
+	// Every running encode, the selected one is controlled by Space/S/D
+	active := m.encodeQueue.GetActive()
+	selected := m.selectedItem()
+	showCursor := len(m.selectableItems()) > 1
+	for _, item := range active {
+		marker := "▶"
+		if showCursor && item == selected {
+			marker = "»"
+		}
+		lines = append(lines, fmt.Sprintf("%s Encoding: %s (%s/AV1)", marker, item.TitleName, item.DiscType))
+		if eta := m.encodeETAs[item.ID]; eta != "" {
+			lines = append(lines, fmt.Sprintf("  ETA: %s", eta))
+		}
+		if done, total := item.SegmentCount(); total > 0 {
+			lines = append(lines, fmt.Sprintf("  Segment %d of %d", min(done+1, total), total))
 		}
-		lines = append(lines, fmt.Sprintf("  %s", m.encodeProgressBar.ViewAs(m.currentEncode.Progress/100.0)))
+		lines = append(lines, fmt.Sprintf("  %s", m.encodeProgressBar.ViewAs(item.Progress/100.0)))
 
-		if m.encodePaused {
+		if m.encodePaused[item.ID] {
 			lines = append(lines, "  [PAUSED] Press Space to resume")
-		} else {
-			lines = append(lines, "  [Space] Pause/Resume")
 		}
+	}
+	if len(active) > 0 {
 		lines = append(lines, "")
 	}
 
-	// Show all items (except currently encoding one)
+	// Show all other items
 	queuedCount := 0
 	completedCount := 0
 	failedCount := 0
 
 	for _, item := range queueItems {
-		// Skip if this is the current encode (already shown above)
-		if m.currentEncode != nil && item.ID == m.currentEncode.ID && item.Status == encode.StatusEncoding {
-			continue
-		}
 
 		switch item.Status {
 		case encode.StatusQueued:
-			lines = append(lines, fmt.Sprintf("⏸ Queued: %s (%s)", item.TitleName, item.DiscType))
+			marker := "⏸"
+			if showCursor && item == selected {
+				marker = "»"
+			}
+			line := fmt.Sprintf("%s Queued: %s (%s)", marker, item.TitleName, item.DiscType)
+			if item.Priority != 0 {
+				line += fmt.Sprintf(" [priority %+d]", item.Priority)
+			}
+			lines = append(lines, line)
 			queuedCount++
 		case encode.StatusComplete:
 			lines = append(lines, fmt.Sprintf("✓ Complete: %s (%s)", item.TitleName, item.DiscType))
@@ -608,8 +1298,7 @@ func (m Model) renderEncodingSection() string {
 	}
 
 	// Show "no items" only if queue is completely empty
-	hasCurrentEncode := m.currentEncode != nil && m.currentEncode.Status == encode.StatusEncoding
-	if queuedCount == 0 && completedCount == 0 && failedCount == 0 && !hasCurrentEncode {
+	if queuedCount == 0 && completedCount == 0 && failedCount == 0 && len(active) == 0 {
 		lines = append(lines, "No items in queue")
 	}
 
@@ -652,6 +1341,63 @@ func (m Model) renderLogSection(usedLines int) string {
 	return "\n" + strings.Join(lines, "\n")
 }
 
+// capitalize upper-cases the first letter of a message
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// selectableItems returns the running encodes followed by the queued items,
+// in the order they are listed
+func (m Model) selectableItems() []*encode.QueueItem {
+	return append(m.encodeQueue.GetActive(), m.encodeQueue.GetQueued()...)
+}
+
+// selectedItem returns the running or queued item under the cursor
+func (m Model) selectedItem() *encode.QueueItem {
+	items := m.selectableItems()
+	if len(items) == 0 {
+		return nil
+	}
+	return items[min(m.queueCursor, len(items)-1)]
+}
+
+// selectedEncode returns the selected item if it is encoding
+func (m Model) selectedEncode() *encode.QueueItem {
+	if item := m.selectedItem(); item != nil && item.Status == encode.StatusEncoding {
+		return item
+	}
+	return nil
+}
+
+// selectedQueued returns the selected item if it is waiting to be encoded
+func (m Model) selectedQueued() *encode.QueueItem {
+	if item := m.selectedItem(); item != nil && item.Status == encode.StatusQueued {
+		return item
+	}
+	return nil
+}
+
+// selectItem moves the cursor to an item, e.g. after it was reordered
+func (m *Model) selectItem(id string) {
+	for i, item := range m.selectableItems() {
+		if item.ID == id {
+			m.queueCursor = i
+			return
+		}
+	}
+}
+
+// trayText names the tray action for a drive's media state
+func trayText(media disk.State) string {
+	if media == disk.StateTrayOpen {
+		return "Close Tray"
+	}
+	return "Open Tray"
+}
+
 func (m Model) renderControls() string {
 	logStatus := "Show"
 	if m.showLogs {
@@ -659,17 +1405,31 @@ func (m Model) renderControls() string {
 	}
 
 	var controls string
-	if m.ripState == StateScanning || m.ripState == StateRipping || m.ripState == StateSelectingTitles {
+	if d := m.drives[m.activeDrive]; d.busy() {
 		controls = fmt.Sprintf("[Q] Quit  [X/E] Cancel & Eject  [C] Clear  [T] Retry  [A] Scan  [L] %s Logs", logStatus)
-	} else if m.currentEncode != nil {
+	} else if item := m.selectedEncode(); item != nil {
 		// Show encode-specific controls when actively encoding
 		pauseText := "Pause"
-		if m.encodePaused {
+		if m.encodePaused[item.ID] {
 			pauseText = "Resume"
 		}
 		controls = fmt.Sprintf("[Q] Quit  [Space] %s  [S] Stop  [D] Delete  [C] Clear  [T] Retry  [A] Scan  [L] %s Logs", pauseText, logStatus)
+		if len(m.selectableItems()) > 1 {
+			controls += "  [↑/↓] Select"
+		}
+	} else if m.selectedQueued() != nil {
+		controls = fmt.Sprintf("[Q] Quit  [↑/↓] Select  [K/J] Move  [G] Top  [+/-] Priority  [D] Remove  [C] Clear  [T] Retry  [A] Scan  [L] %s Logs", logStatus)
 	} else {
-		controls = fmt.Sprintf("[Q] Quit  [C] Clear  [T] Retry  [A] Scan for Missing  [L] %s Logs", logStatus)
+		controls = fmt.Sprintf("[Q] Quit  [O] %s  [C] Clear  [T] Retry  [A] Scan for Missing  [L] %s Logs", trayText(d.media), logStatus)
+	}
+
+	if m.showHistory {
+		controls += "  [/] Search  [H] Hide History"
+	} else {
+		controls += "  [H] History"
+	}
+	if len(m.drives) > 1 {
+		controls += "  [Tab] Next Drive"
 	}
 
 	controlsStyle := lipgloss.NewStyle().Faint(true)